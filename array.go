@@ -0,0 +1,346 @@
+package netcdf4
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+)
+
+// Numeric is the set of Go types variable data can be read into and
+// written from.
+type Numeric interface {
+	int8 | uint8 | int16 | uint16 | int32 | uint32 | int64 | uint64 | float32 | float64
+}
+
+// Array is an N-dimensional array holding its values in row-major (C)
+// order, the order netCDF stores them in. A rank 0 array holds one value.
+type Array[T any] struct {
+	data  []T
+	shape []int
+}
+
+// Range selects the indices Start, Start+Step, ... below Stop along one
+// dimension. A zero Step means 1 and a zero Stop means the end of the
+// dimension, so Range{} selects the whole dimension.
+type Range struct {
+	Start, Stop, Step int
+}
+
+// MakeArray returns an array of the given shape holding zero values.
+func MakeArray[T any](shape ...int) *Array[T] {
+	return &Array[T]{data: make([]T, shapeLen(shape)), shape: append([]int{}, shape...)}
+}
+
+// NewArray returns an array of the given shape backed by data, which must
+// hold exactly the number of values the shape calls for.
+func NewArray[T any](data []T, shape ...int) (*Array[T], error) {
+	if n := shapeLen(shape); n != len(data) {
+		return nil, fmt.Errorf("error: %d values do not fit shape %v of %d values", len(data), shape, n)
+	}
+	return &Array[T]{data: data, shape: append([]int{}, shape...)}, nil
+}
+
+// FromNested converts nested Go slices (or arrays) such as [][]float32 into
+// an array. The nesting must be rectangular; a plain T gives a rank 0 array.
+func FromNested[T any](nested interface{}) (*Array[T], error) {
+	elemType := reflect.TypeOf((*T)(nil)).Elem()
+	rv := reflect.ValueOf(nested)
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("error: FromNested got nil")
+	}
+
+	// the shape follows the first element at every level
+	var shape []int
+	for v := rv; v.Type() != elemType; {
+		if k := v.Kind(); k != reflect.Slice && k != reflect.Array {
+			return nil, fmt.Errorf("error: FromNested cannot convert %T to values of %v", nested, elemType)
+		}
+		shape = append(shape, v.Len())
+		if v.Len() == 0 {
+			for t := v.Type().Elem(); t != elemType; t = t.Elem() {
+				if k := t.Kind(); k != reflect.Slice && k != reflect.Array {
+					return nil, fmt.Errorf("error: FromNested cannot convert %T to values of %v", nested, elemType)
+				}
+				shape = append(shape, 0)
+			}
+			break
+		}
+		v = v.Index(0)
+	}
+
+	a := MakeArray[T](shape...)
+	pos := 0
+	var fill func(v reflect.Value, dim int) error
+	fill = func(v reflect.Value, dim int) error {
+		if dim == len(shape) {
+			a.data[pos] = v.Interface().(T)
+			pos++
+			return nil
+		}
+		if v.Len() != shape[dim] {
+			return fmt.Errorf("error: FromNested got a ragged slice: length %d along dimension %d, want %d", v.Len(), dim, shape[dim])
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := fill(v.Index(i), dim+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := fill(rv, 0); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Shape returns the size of each dimension.
+func (a *Array[T]) Shape() []int {
+	return append([]int{}, a.shape...)
+}
+
+// Rank returns the number of dimensions.
+func (a *Array[T]) Rank() int {
+	return len(a.shape)
+}
+
+// Len returns the number of values.
+func (a *Array[T]) Len() int {
+	return len(a.data)
+}
+
+// Data returns the values in row-major order. The slice is shared with a.
+func (a *Array[T]) Data() []T {
+	return a.data
+}
+
+// Offset returns the position in Data of the value at idx. It panics if idx
+// is out of range, like indexing a slice.
+func (a *Array[T]) Offset(idx ...int) int {
+	if len(idx) != len(a.shape) {
+		panic(fmt.Sprintf("netcdf4: %d indices for an array of rank %d", len(idx), len(a.shape)))
+	}
+	off := 0
+	for i, n := range idx {
+		if n < 0 || n >= a.shape[i] {
+			panic(fmt.Sprintf("netcdf4: index %d out of range [0:%d] along dimension %d", n, a.shape[i], i))
+		}
+		off = off*a.shape[i] + n
+	}
+	return off
+}
+
+// At returns the value at idx. It panics if idx is out of range.
+func (a *Array[T]) At(idx ...int) T {
+	return a.data[a.Offset(idx...)]
+}
+
+// Set stores value at idx. It panics if idx is out of range.
+func (a *Array[T]) Set(value T, idx ...int) {
+	a.data[a.Offset(idx...)] = value
+}
+
+// All returns an iterator over the indices and values in row-major order.
+// The index slice is reused between iterations.
+func (a *Array[T]) All() iter.Seq2[[]int, T] {
+	return func(yield func([]int, T) bool) {
+		if len(a.data) == 0 {
+			return
+		}
+		idx := make([]int, len(a.shape))
+		for i := 0; ; i++ {
+			if !yield(idx, a.data[i]) || !nextIndex(idx, a.shape) {
+				return
+			}
+		}
+	}
+}
+
+// Slice returns a copy of the values selected by ranges, one per leading
+// dimension; the dimensions without a range are taken whole. The rank is
+// kept, see Reshape to drop dimensions of size 1.
+func (a *Array[T]) Slice(ranges ...Range) (*Array[T], error) {
+	if len(ranges) > len(a.shape) {
+		return nil, fmt.Errorf("error: %d ranges for an array of rank %d", len(ranges), len(a.shape))
+	}
+	starts := make([]int, len(a.shape))
+	steps := make([]int, len(a.shape))
+	shape := make([]int, len(a.shape))
+	for i, size := range a.shape {
+		r := Range{}
+		if i < len(ranges) {
+			r = ranges[i]
+		}
+		if r.Step == 0 {
+			r.Step = 1
+		}
+		if r.Stop == 0 {
+			r.Stop = size
+		}
+		if r.Start < 0 || r.Stop > size || r.Start > r.Stop || r.Step < 0 {
+			return nil, fmt.Errorf("error: range %+v out of bounds for dimension %d of size %d", r, i, size)
+		}
+		starts[i], steps[i] = r.Start, r.Step
+		shape[i] = (r.Stop - r.Start + r.Step - 1) / r.Step
+	}
+
+	out := MakeArray[T](shape...)
+	if len(out.data) == 0 {
+		return out, nil
+	}
+	idx := make([]int, len(shape))
+	src := make([]int, len(shape))
+	for i := 0; ; i++ {
+		for d := range idx {
+			src[d] = starts[d] + idx[d]*steps[d]
+		}
+		out.data[i] = a.At(src...)
+		if !nextIndex(idx, shape) {
+			return out, nil
+		}
+	}
+}
+
+// Reshape returns an array of a new shape holding the same values, sharing
+// them with a. One size may be -1, it is then inferred.
+func (a *Array[T]) Reshape(shape ...int) (*Array[T], error) {
+	shape = append([]int{}, shape...)
+	infer, n := -1, 1
+	for i, size := range shape {
+		switch {
+		case size == -1 && infer < 0:
+			infer = i
+		case size < 0:
+			return nil, fmt.Errorf("error: invalid shape %v", shape)
+		default:
+			n *= size
+		}
+	}
+	if infer >= 0 && n > 0 && len(a.data)%n == 0 {
+		shape[infer] = len(a.data) / n
+		n = len(a.data)
+	}
+	if n != len(a.data) {
+		return nil, fmt.Errorf("error: cannot reshape %v to %v", a.shape, shape)
+	}
+	return &Array[T]{data: a.data, shape: shape}, nil
+}
+
+// Transpose returns a copy with the dimensions permuted: dimension i of the
+// result is dimension axes[i] of a. Without axes the order is reversed.
+func (a *Array[T]) Transpose(axes ...int) (*Array[T], error) {
+	rank := len(a.shape)
+	if len(axes) == 0 {
+		axes = make([]int, rank)
+		for i := range axes {
+			axes[i] = rank - 1 - i
+		}
+	}
+	if len(axes) != rank {
+		return nil, fmt.Errorf("error: %d axes for an array of rank %d", len(axes), rank)
+	}
+	seen := make([]bool, rank)
+	shape := make([]int, rank)
+	for i, ax := range axes {
+		if ax < 0 || ax >= rank || seen[ax] {
+			return nil, fmt.Errorf("error: axes %v are not a permutation of the dimensions", axes)
+		}
+		seen[ax] = true
+		shape[i] = a.shape[ax]
+	}
+
+	out := MakeArray[T](shape...)
+	if len(out.data) == 0 {
+		return out, nil
+	}
+	idx := make([]int, rank)
+	src := make([]int, rank)
+	for i := 0; ; i++ {
+		for d, ax := range axes {
+			src[ax] = idx[d]
+		}
+		out.data[i] = a.At(src...)
+		if !nextIndex(idx, shape) {
+			return out, nil
+		}
+	}
+}
+
+// Nested converts the array to nested Go slices, e.g. [][]float32 for a
+// rank 2 Array[float32]; a rank 0 array gives its single value.
+func (a *Array[T]) Nested() interface{} {
+	if len(a.shape) == 0 {
+		return a.data[0]
+	}
+	return nestedValue(reflect.ValueOf(append([]T{}, a.data...)), a.shape).Interface()
+}
+
+func nestedValue(flat reflect.Value, shape []int) reflect.Value {
+	if len(shape) == 1 {
+		return flat
+	}
+	t := flat.Type()
+	for range shape[1:] {
+		t = reflect.SliceOf(t)
+	}
+	out := reflect.MakeSlice(t, shape[0], shape[0])
+	stride := shapeLen(shape[1:])
+	for i := 0; i < shape[0]; i++ {
+		out.Index(i).Set(nestedValue(flat.Slice(i*stride, (i+1)*stride), shape[1:]))
+	}
+	return out
+}
+
+// flat returns the values as a plain slice, see unwrapArray.
+func (a *Array[T]) flat() interface{} {
+	return a.data
+}
+
+// unwrapArray returns the values of data if it is an *Array, so that the
+// functions taking slices accept arrays as well.
+func unwrapArray(data interface{}) interface{} {
+	if a, ok := data.(interface{ flat() interface{} }); ok {
+		return a.flat()
+	}
+	return data
+}
+
+// nextIndex advances idx to the next index in row-major order and returns
+// false once all indices of shape have been visited.
+func nextIndex(idx, shape []int) bool {
+	for d := len(idx) - 1; d >= 0; d-- {
+		idx[d]++
+		if idx[d] < shape[d] {
+			return true
+		}
+		idx[d] = 0
+	}
+	return false
+}
+
+// shapeLen returns the number of values in an array of the given shape.
+func shapeLen(shape []int) int {
+	n := 1
+	for _, size := range shape {
+		n *= size
+	}
+	return n
+}
+
+// ReadArray reads the whole variable into an array of its shape.
+func ReadArray[T Numeric](v Var) (*Array[T], error) {
+	shape, err := v.Shape()
+	if err != nil {
+		return nil, err
+	}
+	return ReadArraySlab[T](v, make([]int, len(shape)), shape)
+}
+
+// ReadArraySlab reads the hyperslab that begins at start and spans count
+// values along each dimension into an array of shape count.
+func ReadArraySlab[T Numeric](v Var, start, count []int) (*Array[T], error) {
+	a := MakeArray[T](count...)
+	if err := v.GetSlab(start, count, a.data); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
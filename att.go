@@ -0,0 +1,258 @@
+package netcdf4
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Att represents an attribute attached to a variable or, when its variable
+// ID is NCGLOBAL, to a group (a global attribute).
+type Att struct {
+	nullObject bool
+	name       string
+	varId      ID
+	groupId    ID
+}
+
+// NewAttNull returns an attribute configured to be a null attribute
+func NewAttNull() (a Att) {
+	a.nullObject = true
+	a.varId = -1
+	a.groupId = -1
+	return
+}
+
+// NewAtt returns the attribute name of variable varID in group groupID.
+// Use NCGLOBAL as varID for a group attribute.
+func NewAtt(groupID ID, varID ID, name string) (a Att) {
+	a.nullObject = false
+	a.name = name
+	a.varId = varID
+	a.groupId = groupID
+	return
+}
+
+// IsNull returns true if this object is null (i.e. it has no contents); otherwise returns false.
+func (a Att) IsNull() bool {
+	return a.nullObject
+}
+
+// Name returns the name of the attribute.
+func (a Att) Name() string {
+	return a.name
+}
+
+// IsGlobal returns true if the attribute belongs to a group rather than a variable.
+func (a Att) IsGlobal() bool {
+	return a.varId == NCGLOBAL
+}
+
+// RenameTo attempts to rename the attribute to name. a still refers to the
+// old name afterwards; get the attribute again by its new name to use it.
+func (a Att) RenameTo(name string) error {
+	if a.IsNull() {
+		return fmt.Errorf("error: attempt to invoke RenameTo on a Null attribute")
+	}
+	CheckDefineMode(a.groupId)
+	return NcRenameAtt(a.groupId, a.varId, a.name, name)
+}
+
+// GetParentGroup gets the group the attribute (or its variable) belongs to.
+func (a Att) GetParentGroup() *Group {
+	return NewGroup(a.groupId)
+}
+
+// GetParentVar gets the variable the attribute is attached to, a null Var for global attributes.
+func (a Att) GetParentVar() Var {
+	if a.IsNull() || a.IsGlobal() {
+		return NewVarNull()
+	}
+	return NewVar(*a.GetParentGroup(), a.varId)
+}
+
+// GetType gets the type of the attribute values.
+func (a Att) GetType() (Type, error) {
+	if a.IsNull() {
+		return NewTypeNull(), fmt.Errorf("error: attempt to invoke GetType on a Null attribute")
+	}
+	xtype, err := ncInqAtttype(a.groupId, a.varId, a.name)
+	if err != nil {
+		return NewTypeNull(), err
+	}
+	t := NewType(xtype)
+	if t.IsComplex() {
+		// user defined types are not supported yet
+		return NewTypeNull(), nil
+	}
+	return t, nil
+}
+
+// Len returns the number of values stored in the attribute; for text
+// attributes this is the number of characters.
+func (a Att) Len() (int, error) {
+	if a.IsNull() {
+		return 0, fmt.Errorf("error: attempt to invoke Len on a Null attribute")
+	}
+	return ncInqAttlen(a.groupId, a.varId, a.name)
+}
+
+// Values reads the attribute values: a string for a text attribute, a
+// []string for a String attribute and otherwise a slice of the Go type
+// matching the attribute type, e.g. []float32 for Float.
+func (a Att) Values() (interface{}, error) {
+	t, err := a.GetType()
+	if err != nil {
+		return nil, err
+	}
+	n, err := a.Len()
+	if err != nil {
+		return nil, err
+	}
+	switch t.GetId() {
+	case Char.GetId():
+		return a.Text()
+	case String.GetId():
+		return ncGetAttString(a.groupId, a.varId, a.name, n)
+	}
+	if t.IsNull() {
+		return nil, fmt.Errorf("error: attribute %q has a user defined type", a.name)
+	}
+	data, err := t.newSlice(n)
+	if err != nil {
+		return nil, err
+	}
+	if err := ncGetAtt(a.groupId, a.varId, a.name, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Float64s reads the values of a numeric attribute converted to float64.
+func (a Att) Float64s() ([]float64, error) {
+	n, err := a.Len()
+	if err != nil {
+		return nil, err
+	}
+	data := make([]float64, n)
+	if err := ncGetAtt(a.groupId, a.varId, a.name, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Text reads a text attribute, or a String attribute holding one value, as
+// a Go string. Trailing NUL characters are dropped.
+func (a Att) Text() (string, error) {
+	t, err := a.GetType()
+	if err != nil {
+		return "", err
+	}
+	n, err := a.Len()
+	if err != nil {
+		return "", err
+	}
+	switch t.GetId() {
+	case Char.GetId():
+		buf := make([]byte, n)
+		if err := ncGetAttText(a.groupId, a.varId, a.name, buf); err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(buf), "\x00"), nil
+	case String.GetId():
+		if n != 1 {
+			return "", fmt.Errorf("error: attribute %q holds %d strings", a.name, n)
+		}
+		strs, err := ncGetAttString(a.groupId, a.varId, a.name, n)
+		if err != nil {
+			return "", err
+		}
+		return strs[0], nil
+	default:
+		return "", fmt.Errorf("error: attribute %q is not text", a.name)
+	}
+}
+
+// getAtt returns the attribute name of varID in groupID, or an error if
+// there is no such attribute.
+func getAtt(groupID ID, varID ID, name string) (Att, error) {
+	if _, err := ncInqAtttype(groupID, varID, name); err != nil {
+		return NewAttNull(), fmt.Errorf("error: no attribute %q: %v", name, err)
+	}
+	return NewAtt(groupID, varID, name), nil
+}
+
+// putAtt writes value as the attribute name of varID in groupID: a string
+// as text, a []string as String values, and a number or a slice of numbers
+// with the matching netCDF type. Go int values are written as Int when they
+// all fit, as classic files have no Int64, and as Int64 otherwise.
+func putAtt(groupID ID, varID ID, name string, value interface{}) (Att, error) {
+	var err error
+	switch x := value.(type) {
+	case string:
+		err = ncPutAttText(groupID, varID, name, x)
+	case []string:
+		err = ncPutAttString(groupID, varID, name, x)
+	case nil:
+		err = fmt.Errorf("error: no value for attribute %q", name)
+	case int:
+		return putAtt(groupID, varID, name, []int{x})
+	case []int:
+		fits := true
+		for _, n := range x {
+			fits = fits && n >= math.MinInt32 && n <= math.MaxInt32
+		}
+		if fits {
+			data := make([]int32, len(x))
+			for i, n := range x {
+				data[i] = int32(n)
+			}
+			return putAtt(groupID, varID, name, data)
+		}
+		data := make([]int64, len(x))
+		for i, n := range x {
+			data[i] = int64(n)
+		}
+		return putAtt(groupID, varID, name, data)
+	default:
+		data := value
+		if reflect.ValueOf(value).Kind() != reflect.Slice {
+			// a single number
+			s := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(value)), 1, 1)
+			s.Index(0).Set(reflect.ValueOf(value))
+			data = s.Interface()
+		}
+		var t Type
+		if t, err = sliceType(data); err == nil {
+			err = ncPutAtt(groupID, varID, name, t.GetId(), data)
+		}
+	}
+	if err != nil {
+		return NewAttNull(), err
+	}
+	return NewAtt(groupID, varID, name), nil
+}
+
+// getAtts returns the attributes of varID in groupID in the order they are stored.
+func getAtts(groupID ID, varID ID) ([]Att, error) {
+	var nAtts int
+	var err error
+	if varID == NCGLOBAL {
+		nAtts, err = ncInqNatts(groupID)
+	} else {
+		nAtts, err = ncInqVarnatts(groupID, varID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	atts := make([]Att, nAtts)
+	for i := 0; i < nAtts; i++ {
+		name, err := ncInqAttname(groupID, varID, i)
+		if err != nil {
+			return nil, err
+		}
+		atts[i] = NewAtt(groupID, varID, name)
+	}
+	return atts, nil
+}
@@ -0,0 +1,203 @@
+package netcdf4
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bounds gets the variable named by the 'bounds' attribute of a coordinate
+// variable, or by its 'climatology' attribute for a climatological time
+// axis. The bounds variable has the dimensions of v followed by one for the
+// vertices of each cell, so it is (n, 2) for a 1-D coordinate. ok is false
+// if v has neither attribute.
+func (v Var) Bounds() (bounds Var, ok bool, err error) {
+	if v.IsNull() {
+		return NewVarNull(), false, fmt.Errorf("error: attempt to invoke Bounds on a Null variable")
+	}
+	att, err := v.GetAtt("bounds")
+	if err != nil {
+		if att, err = v.GetAtt("climatology"); err != nil {
+			return NewVarNull(), false, nil
+		}
+	}
+	name, err := att.Text()
+	if err != nil {
+		return NewVarNull(), false, err
+	}
+	name = strings.TrimSpace(name)
+	bounds, ok = findVar(v.GetParentGroup(), name)
+	if !ok {
+		return NewVarNull(), false, fmt.Errorf("error: bounds variable %q not found", name)
+	}
+
+	dims, err := v.GetDims()
+	if err != nil {
+		return NewVarNull(), false, err
+	}
+	bDims, err := bounds.GetDims()
+	if err != nil {
+		return NewVarNull(), false, err
+	}
+	if len(bDims) != len(dims)+1 {
+		return NewVarNull(), false, fmt.Errorf("error: bounds variable %q has %d dimensions, want %d", name, len(bDims), len(dims)+1)
+	}
+	for i, dim := range dims {
+		size, err := dim.GetSize()
+		if err != nil {
+			return NewVarNull(), false, err
+		}
+		bSize, err := bDims[i].GetSize()
+		if err != nil {
+			return NewVarNull(), false, err
+		}
+		if size != bSize {
+			return NewVarNull(), false, fmt.Errorf("error: dimension %d of bounds variable %q has size %d, want %d", i, name, bSize, size)
+		}
+	}
+	return bounds, true, nil
+}
+
+// CellMethod is one entry of a CF 'cell_methods' attribute, such as
+// "time: mean (interval: 1 hr)" or "area: mean where sea_ice over sea".
+type CellMethod struct {
+	// Names are the dimensions, coordinates or standard names ("area") the
+	// method applies to.
+	Names []string
+	// Method is the operation, e.g. "mean", "maximum" or "point" for
+	// instantaneous values.
+	Method string
+	// Where, Over and Within hold the arguments of the qualifiers of the
+	// same name, empty if absent.
+	Where  string
+	Over   string
+	Within string
+	// Intervals holds the "interval:" values of the comment, e.g. "1 hr",
+	// one per name when several are given.
+	Intervals []string
+	// Comment holds the rest of the parenthesised text.
+	Comment string
+}
+
+// CellMethods parses the 'cell_methods' attribute of the variable; it
+// returns nothing if the variable has none.
+func (v Var) CellMethods() ([]CellMethod, error) {
+	if v.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke CellMethods on a Null variable")
+	}
+	att, err := v.GetAtt("cell_methods")
+	if err != nil {
+		return nil, nil
+	}
+	text, err := att.Text()
+	if err != nil {
+		return nil, err
+	}
+	return ParseCellMethods(text)
+}
+
+// ParseCellMethods parses a CF 'cell_methods' string such as
+// "time: mean (interval: 1 hr) area: maximum" into its entries, in order.
+func ParseCellMethods(s string) ([]CellMethod, error) {
+	tokens, err := cellMethodTokens(s)
+	if err != nil {
+		return nil, err
+	}
+	var methods []CellMethod
+	for i := 0; i < len(tokens); {
+		var m CellMethod
+		for i < len(tokens) && isCellName(tokens[i]) {
+			m.Names = append(m.Names, strings.TrimSuffix(tokens[i], ":"))
+			i++
+		}
+		if len(m.Names) == 0 {
+			return nil, fmt.Errorf("error: cell_methods %q: expected a name followed by ':' at %q", s, tokens[i])
+		}
+		if i == len(tokens) || strings.HasPrefix(tokens[i], "(") {
+			return nil, fmt.Errorf("error: cell_methods %q: no method for %s", s, strings.Join(m.Names, ", "))
+		}
+		m.Method = tokens[i]
+		i++
+
+		// qualifiers and comment up to the next entry
+		for i < len(tokens) && !isCellName(tokens[i]) {
+			tok := tokens[i]
+			if strings.HasPrefix(tok, "(") {
+				m.Intervals, m.Comment = parseCellComment(tok[1 : len(tok)-1])
+				i++
+				continue
+			}
+			if i+1 == len(tokens) || isCellName(tokens[i+1]) || strings.HasPrefix(tokens[i+1], "(") {
+				return nil, fmt.Errorf("error: cell_methods %q: unexpected %q", s, tok)
+			}
+			switch tok {
+			case "where":
+				m.Where = tokens[i+1]
+			case "over":
+				m.Over = tokens[i+1]
+			case "within":
+				m.Within = tokens[i+1]
+			default:
+				return nil, fmt.Errorf("error: cell_methods %q: unexpected %q", s, tok)
+			}
+			i += 2
+		}
+		methods = append(methods, m)
+	}
+	return methods, nil
+}
+
+func isCellName(tok string) bool {
+	return len(tok) > 1 && strings.HasSuffix(tok, ":") && !strings.HasPrefix(tok, "(")
+}
+
+// cellMethodTokens splits s at white space, keeping parenthesised text,
+// parentheses included, as one token.
+func cellMethodTokens(s string) ([]string, error) {
+	var tokens []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] == '(' {
+			end := strings.IndexByte(s, ')')
+			if end < 0 {
+				return nil, fmt.Errorf("error: cell_methods: unbalanced parenthesis in %q", s)
+			}
+			tokens = append(tokens, s[:end+1])
+			s = s[end+1:]
+			continue
+		}
+		end := strings.IndexAny(s, " \t\n\r(")
+		if end < 0 {
+			end = len(s)
+		}
+		tokens = append(tokens, s[:end])
+		s = s[end:]
+	}
+	return tokens, nil
+}
+
+// parseCellComment splits the text of a cell method's parentheses into its
+// "interval:" values and the rest, which may follow "comment:".
+func parseCellComment(text string) (intervals []string, comment string) {
+	fields := strings.Fields(text)
+	var rest []string
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "interval:":
+			// an interval is a value and, normally, a unit
+			if i+1 < len(fields) {
+				end := i + 2
+				if end < len(fields) && fields[end] != "interval:" && fields[end] != "comment:" {
+					end++
+				}
+				intervals = append(intervals, strings.Join(fields[i+1:end], " "))
+				i = end - 1
+				continue
+			}
+		case "comment:":
+			rest = append(rest, fields[i+1:]...)
+			i = len(fields)
+			continue
+		}
+		rest = append(rest, fields[i])
+	}
+	return intervals, strings.Join(rest, " ")
+}
@@ -0,0 +1,68 @@
+package netcdf4
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCellMethods(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []CellMethod
+	}{
+		{"time: mean", []CellMethod{{Names: []string{"time"}, Method: "mean"}}},
+		{"time: point", []CellMethod{{Names: []string{"time"}, Method: "point"}}},
+		{"lat: lon: standard_deviation", []CellMethod{{Names: []string{"lat", "lon"}, Method: "standard_deviation"}}},
+		{"time: mean (interval: 1 hr)", []CellMethod{
+			{Names: []string{"time"}, Method: "mean", Intervals: []string{"1 hr"}},
+		}},
+		{"time: maximum (interval: 1 hr) time: mean area: mean", []CellMethod{
+			{Names: []string{"time"}, Method: "maximum", Intervals: []string{"1 hr"}},
+			{Names: []string{"time"}, Method: "mean"},
+			{Names: []string{"area"}, Method: "mean"},
+		}},
+		{"area: mean where sea_ice over sea", []CellMethod{
+			{Names: []string{"area"}, Method: "mean", Where: "sea_ice", Over: "sea"},
+		}},
+		{"time: minimum within days time: mean over days", []CellMethod{
+			{Names: []string{"time"}, Method: "minimum", Within: "days"},
+			{Names: []string{"time"}, Method: "mean", Over: "days"},
+		}},
+		{"lat: lon: mean (interval: 0.1 degree_N interval: 0.2 degree_E)", []CellMethod{
+			{Names: []string{"lat", "lon"}, Method: "mean", Intervals: []string{"0.1 degree_N", "0.2 degree_E"}},
+		}},
+		{"time: mean (interval: 1 day comment: sampled every 3 hours)", []CellMethod{
+			{Names: []string{"time"}, Method: "mean", Intervals: []string{"1 day"}, Comment: "sampled every 3 hours"},
+		}},
+		{"time: mean (ARGO float data)", []CellMethod{
+			{Names: []string{"time"}, Method: "mean", Comment: "ARGO float data"},
+		}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := ParseCellMethods(tt.s)
+		if err != nil {
+			t.Errorf("ParseCellMethods(%q): %v", tt.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCellMethods(%q) =\n%+v, want\n%+v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestParseCellMethodsErrors(t *testing.T) {
+	for _, s := range []string{
+		"mean",
+		"time:",
+		"time: (interval: 1 hr)",
+		"time: mean where",
+		"time: mean sometimes sea",
+		"time: mean (interval: 1 hr",
+		"time:mean(interval: 6 hours)",
+	} {
+		if got, err := ParseCellMethods(s); err == nil {
+			t.Errorf("ParseCellMethods(%q) = %+v, want an error", s, got)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package netcdf4
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/NCAR/netcdf4-go/units"
+)
+
+// Packing describes how the stored values of a variable map to physical
+// values under the CF conventions: a stored value x reads as
+// x*ScaleFactor + AddOffset, or as NaN when it is the fill value, one of the
+// missing values or outside the valid range. The fill, missing and valid
+// values are in stored units.
+type Packing struct {
+	// Type is the stored type of the variable.
+	Type Type
+	// Unsigned is set when integers are stored unsigned in a signed type,
+	// the _Unsigned attribute of netCDF-3 files.
+	Unsigned      bool
+	ScaleFactor   float64
+	AddOffset     float64
+	HasFillValue  bool
+	FillValue     float64
+	MissingValues []float64
+	// ValidMin and ValidMax are infinite when unset.
+	ValidMin float64
+	ValidMax float64
+}
+
+// Packing reads the packing of the variable from its scale_factor,
+// add_offset, _FillValue, missing_value, valid_min, valid_max, valid_range
+// and _Unsigned attributes. Without a _FillValue the default fill value of
+// the type is used.
+func (v Var) Packing() (Packing, error) {
+	t, err := v.GetType()
+	if err != nil {
+		return Packing{}, err
+	}
+	if _, err := t.newSlice(0); err != nil {
+		return Packing{}, fmt.Errorf("error: a variable of type %d cannot be unpacked", t.GetId())
+	}
+	p := Packing{Type: t, ScaleFactor: 1, ValidMin: math.Inf(-1), ValidMax: math.Inf(1)}
+
+	if att, err := v.GetAtt("_Unsigned"); err == nil {
+		text, err := att.Text()
+		if err != nil {
+			return Packing{}, err
+		}
+		_, signed, isInt := t.intBits()
+		p.Unsigned = isInt && signed && strings.EqualFold(strings.TrimSpace(text), "true")
+	}
+
+	// values reads a numeric attribute, nil if it is missing
+	values := func(name string) ([]float64, error) {
+		att, err := v.GetAtt(name)
+		if err != nil {
+			return nil, nil
+		}
+		return att.Float64s()
+	}
+	if x, err := values("scale_factor"); err != nil {
+		return Packing{}, err
+	} else if len(x) > 0 {
+		p.ScaleFactor = x[0]
+	}
+	if x, err := values("add_offset"); err != nil {
+		return Packing{}, err
+	} else if len(x) > 0 {
+		p.AddOffset = x[0]
+	}
+	if x, err := values("_FillValue"); err != nil {
+		return Packing{}, err
+	} else if len(x) > 0 {
+		p.HasFillValue, p.FillValue = true, p.stored(x[0])
+	} else {
+		fillType := t
+		if p.Unsigned {
+			fillType = unsignedType(t)
+		}
+		p.FillValue, p.HasFillValue = fillType.defaultFill()
+	}
+	if x, err := values("missing_value"); err != nil {
+		return Packing{}, err
+	} else {
+		for _, m := range x {
+			p.MissingValues = append(p.MissingValues, p.stored(m))
+		}
+	}
+	if x, err := values("valid_range"); err != nil {
+		return Packing{}, err
+	} else if len(x) == 2 {
+		p.ValidMin, p.ValidMax = p.stored(x[0]), p.stored(x[1])
+	} else {
+		if x, err := values("valid_min"); err != nil {
+			return Packing{}, err
+		} else if len(x) > 0 {
+			p.ValidMin = p.stored(x[0])
+		}
+		if x, err := values("valid_max"); err != nil {
+			return Packing{}, err
+		} else if len(x) > 0 {
+			p.ValidMax = p.stored(x[0])
+		}
+	}
+	return p, nil
+}
+
+// NewPacking returns a packing that stores values between min and max in
+// the integer type t with the best resolution t allows. The most negative
+// value of a signed type, or the largest value of an unsigned one, is kept
+// free as the fill value for missing data.
+func NewPacking(t Type, min, max float64) (Packing, error) {
+	bits, signed, ok := t.intBits()
+	if !ok || bits > 32 {
+		return Packing{}, fmt.Errorf("error: cannot pack into type %d, use an integer type of at most 32 bits", t.GetId())
+	}
+	if math.IsNaN(min) || math.IsNaN(max) || math.IsInf(min, 0) || math.IsInf(max, 0) || min > max {
+		return Packing{}, fmt.Errorf("error: invalid packing range [%v, %v]", min, max)
+	}
+	p := Packing{Type: t, ValidMin: math.Inf(-1), ValidMax: math.Inf(1), HasFillValue: true}
+	steps := math.Exp2(float64(bits)) - 2
+	p.ScaleFactor = (max - min) / steps
+	if p.ScaleFactor == 0 {
+		p.ScaleFactor = 1
+	}
+	if signed {
+		// stored values run from -steps/2 to steps/2
+		p.AddOffset = (min + max) / 2
+		p.FillValue = -math.Exp2(float64(bits - 1))
+	} else {
+		// stored values run from 0 to steps
+		p.AddOffset = min
+		p.FillValue = steps + 1
+	}
+	return p, nil
+}
+
+// Unpack converts a stored value to its physical value, NaN if it is
+// missing.
+func (p Packing) Unpack(x float64) float64 {
+	if p.IsMissing(x) {
+		return math.NaN()
+	}
+	return x*p.ScaleFactor + p.AddOffset
+}
+
+// IsMissing returns true if the stored value x is the fill value, one of the
+// missing values or outside the valid range.
+func (p Packing) IsMissing(x float64) bool {
+	if math.IsNaN(x) || (p.HasFillValue && x == p.FillValue) || x < p.ValidMin || x > p.ValidMax {
+		return true
+	}
+	for _, m := range p.MissingValues {
+		if x == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Pack converts a physical value to its stored value, rounded for integer
+// types; NaN packs to the fill value. The stored value is in unsigned form
+// when Unsigned is set.
+func (p Packing) Pack(value float64) (float64, error) {
+	if math.IsNaN(value) {
+		if !p.HasFillValue {
+			return 0, fmt.Errorf("error: cannot pack NaN without a fill value")
+		}
+		return p.FillValue, nil
+	}
+	x := (value - p.AddOffset) / p.ScaleFactor
+	lo, hi, isInt := p.storedRange()
+	if !isInt {
+		return x, nil
+	}
+	x = math.Round(x)
+	if x < lo || x > hi {
+		return 0, fmt.Errorf("error: %v cannot be packed, it is out of range", value)
+	}
+	return x, nil
+}
+
+// storedRange returns the range of stored values that are not the fill
+// value for integer types.
+func (p Packing) storedRange() (lo, hi float64, isInt bool) {
+	bits, signed, ok := p.Type.intBits()
+	if !ok {
+		return math.Inf(-1), math.Inf(1), false
+	}
+	if p.Unsigned {
+		signed = false
+	}
+	if signed {
+		lo, hi = -math.Exp2(float64(bits-1)), math.Exp2(float64(bits-1))-1
+	} else {
+		lo, hi = 0, math.Exp2(float64(bits))-1
+	}
+	if p.HasFillValue && p.FillValue == lo {
+		lo++
+	}
+	if p.HasFillValue && p.FillValue == hi {
+		hi--
+	}
+	return lo, hi, true
+}
+
+// stored reinterprets a value read from a signed integer as unsigned when
+// the Unsigned flag is set.
+func (p Packing) stored(x float64) float64 {
+	if !p.Unsigned || x >= 0 {
+		return x
+	}
+	bits, _, _ := p.Type.intBits()
+	return x + math.Exp2(float64(bits))
+}
+
+// signedForm is the inverse of stored, the value to hand to the library.
+func (p Packing) signedForm(x float64) float64 {
+	if !p.Unsigned {
+		return x
+	}
+	bits, _, _ := p.Type.intBits()
+	if x >= math.Exp2(float64(bits-1)) {
+		return x - math.Exp2(float64(bits))
+	}
+	return x
+}
+
+// unsignedType returns the unsigned counterpart of a signed integer type.
+func unsignedType(t Type) Type {
+	switch t.GetId() {
+	case Byte.GetId():
+		return Ubyte
+	case Short.GetId():
+		return Ushort
+	case Int.GetId():
+		return Uint
+	case Int64.GetId():
+		return Uint64
+	default:
+		return t
+	}
+}
+
+// ReadOption configures Var.ReadUnpacked and Var.ReadUnpackedSlab.
+type ReadOption func(*readConfig)
+
+type readConfig struct {
+	units string // units to convert to, "" to keep those of the variable
+}
+
+// ReadInUnits converts the values from the units in the 'units' attribute
+// of the variable to to, in the UDUNITS syntax of package units, e.g.
+// "hPa" for a pressure in Pa or "kg m-2 d-1" for a precipitation flux in
+// kg m-2 s-1. The units must have the same dimensions.
+func ReadInUnits(to string) ReadOption {
+	return func(c *readConfig) { c.units = to }
+}
+
+// ReadUnpacked reads the whole variable and unpacks it, see Packing.
+func (v Var) ReadUnpacked(opts ...ReadOption) (*Array[float64], error) {
+	shape, err := v.Shape()
+	if err != nil {
+		return nil, err
+	}
+	return v.ReadUnpackedSlab(make([]int, len(shape)), shape, opts...)
+}
+
+// ReadUnpackedSlab reads the hyperslab that begins at start and spans count
+// values along each dimension and unpacks it, see Packing.
+func (v Var) ReadUnpackedSlab(start, count []int, opts ...ReadOption) (*Array[float64], error) {
+	cfg := &readConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	conv := units.Converter{Scale: 1}
+	if cfg.units != "" {
+		var err error
+		if conv, err = v.unitsConverter(cfg.units); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := v.Packing()
+	if err != nil {
+		return nil, err
+	}
+	n := 1
+	for _, c := range count {
+		n *= c
+	}
+	// read in the stored type so that fill values compare exactly
+	data, err := p.Type.newSlice(n)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.GetSlab(start, count, data); err != nil {
+		return nil, err
+	}
+	out := MakeArray[float64](count...)
+	if err := storedValues(data, p.Unsigned, out.data); err != nil {
+		return nil, err
+	}
+	for i, x := range out.data {
+		out.data[i] = p.Unpack(x)
+	}
+	conv.ConvertSlice(out.data)
+	return out, nil
+}
+
+// unitsConverter returns the conversion from the units of the variable to
+// the units to.
+func (v Var) unitsConverter(to string) (units.Converter, error) {
+	att, err := v.GetAtt("units")
+	if err != nil {
+		return units.Converter{}, fmt.Errorf("error: the variable has no units to convert from")
+	}
+	text, err := att.Text()
+	if err != nil {
+		return units.Converter{}, err
+	}
+	from, err := units.Parse(text)
+	if err != nil {
+		return units.Converter{}, err
+	}
+	target, err := units.Parse(to)
+	if err != nil {
+		return units.Converter{}, err
+	}
+	return from.Converter(target)
+}
+
+// storedValues converts data, a slice of a fixed size numeric type, to
+// float64, reading signed integers as unsigned if unsigned is set.
+func storedValues(data interface{}, unsigned bool, out []float64) error {
+	switch d := data.(type) {
+	case []int8:
+		for i, x := range d {
+			if unsigned {
+				out[i] = float64(uint8(x))
+			} else {
+				out[i] = float64(x)
+			}
+		}
+	case []uint8:
+		for i, x := range d {
+			out[i] = float64(x)
+		}
+	case []int16:
+		for i, x := range d {
+			if unsigned {
+				out[i] = float64(uint16(x))
+			} else {
+				out[i] = float64(x)
+			}
+		}
+	case []uint16:
+		for i, x := range d {
+			out[i] = float64(x)
+		}
+	case []int32:
+		for i, x := range d {
+			if unsigned {
+				out[i] = float64(uint32(x))
+			} else {
+				out[i] = float64(x)
+			}
+		}
+	case []uint32:
+		for i, x := range d {
+			out[i] = float64(x)
+		}
+	case []int64:
+		for i, x := range d {
+			if unsigned {
+				out[i] = float64(uint64(x))
+			} else {
+				out[i] = float64(x)
+			}
+		}
+	case []uint64:
+		for i, x := range d {
+			out[i] = float64(x)
+		}
+	case []float32:
+		for i, x := range d {
+			out[i] = float64(x)
+		}
+	case []float64:
+		copy(out, d)
+	default:
+		return fmt.Errorf("error: unsupported data type %T", data)
+	}
+	return nil
+}
+
+// DefPacking writes p as the scale_factor, add_offset, _FillValue and
+// _Unsigned attributes of the variable, whose type must be p.Type. As a
+// _FillValue cannot change once data is written, call it before writing.
+func (v Var) DefPacking(p Packing) error {
+	if v.IsNull() {
+		return fmt.Errorf("error: attempt to invoke DefPacking on a Null variable")
+	}
+	t, err := v.GetType()
+	if err != nil {
+		return err
+	}
+	if t.GetId() != p.Type.GetId() {
+		return fmt.Errorf("error: packing for type %d on a variable of type %d", p.Type.GetId(), t.GetId())
+	}
+	if _, err := v.PutAtt("scale_factor", p.ScaleFactor); err != nil {
+		return err
+	}
+	if _, err := v.PutAtt("add_offset", p.AddOffset); err != nil {
+		return err
+	}
+	if p.HasFillValue {
+		if err := ncPutAtt(v.groupId, v.myId, "_FillValue", t.GetId(), []float64{p.signedForm(p.FillValue)}); err != nil {
+			return err
+		}
+	}
+	if p.Unsigned {
+		if _, err := v.PutAtt("_Unsigned", "true"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PutPacked packs values, the physical values of the whole variable, with
+// the packing read from its attributes and writes them.
+func (v Var) PutPacked(values []float64) error {
+	shape, err := v.Shape()
+	if err != nil {
+		return err
+	}
+	return v.PutPackedSlab(make([]int, len(shape)), shape, values)
+}
+
+// PutPackedSlab packs values with the packing read from the variable's
+// attributes and writes them into the hyperslab that begins at start and
+// spans count values along each dimension. NaN values are written as the
+// fill value.
+func (v Var) PutPackedSlab(start, count []int, values []float64) error {
+	p, err := v.Packing()
+	if err != nil {
+		return err
+	}
+	data := make([]float64, len(values))
+	for i, value := range values {
+		x, err := p.Pack(value)
+		if err != nil {
+			return err
+		}
+		data[i] = p.signedForm(x)
+	}
+	return v.PutSlab(start, count, data)
+}
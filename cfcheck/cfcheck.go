@@ -0,0 +1,418 @@
+// Package cfcheck reports where a netCDF file departs from the CF
+// conventions (https://cfconventions.org): missing or invalid units, units
+// not convertible to the canonical units of the standard name, unknown
+// standard names, dangling coordinates, bounds and grid_mapping references,
+// non-monotonic coordinate variables, bad calendars and time units, and
+// _FillValue or missing_value attributes of the wrong type.
+//
+// It checks a practical subset of the conventions, not every rule of the
+// CF-1.x documents.
+package cfcheck
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/NCAR/netcdf4-go"
+	"github.com/NCAR/netcdf4-go/units"
+)
+
+// Severity is the severity of an Issue.
+type Severity int
+
+// Known severities
+const (
+	Warning Severity = iota // A recommendation is not followed, or a check was inconclusive.
+	Error                   // A requirement is not met.
+)
+
+// String conforms to fmt.Stringer interface
+func (s Severity) String() string {
+	if s == Error {
+		return "ERROR"
+	}
+	return "WARNING"
+}
+
+// Issue is one conformance problem.
+type Issue struct {
+	Severity Severity
+	// Path is the object the issue is about, as netcdf4.Group.Walk writes
+	// it, e.g. "/temp" or "/temp:units".
+	Path    string
+	Message string
+}
+
+// String formats the issue as "ERROR /temp: message".
+func (i Issue) String() string {
+	return fmt.Sprintf("%v %s: %s", i.Severity, i.Path, i.Message)
+}
+
+// Checker checks files against the CF conventions.
+type Checker struct {
+	// StandardNames is the table standard names and their canonical units
+	// are checked against. If it is nil the bundled table is used. Names not
+	// found in the table are errors; the bundled table is only a subset of
+	// the CF table unless it was regenerated, pass the full table to check
+	// names outside it.
+	StandardNames *StandardNameTable
+}
+
+// Check checks an open file with the default Checker.
+func Check(f *netcdf4.File) ([]Issue, error) {
+	return (&Checker{}).Check(f.Group)
+}
+
+// HasErrors returns true if any of issues is an Error.
+func HasErrors(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Check checks the group g, normally the root group of a file, and all
+// groups beneath it. The returned error reports a failure to read the file,
+// not a conformance problem.
+func (c *Checker) Check(g *netcdf4.Group) ([]Issue, error) {
+	table := c.StandardNames
+	if table == nil {
+		table = BundledStandardNames()
+	}
+	ck := &check{table: table}
+
+	if isRoot, err := g.IsRootGroup(); err != nil {
+		return nil, err
+	} else if isRoot {
+		if err := ck.conventions(g); err != nil {
+			return nil, err
+		}
+	}
+
+	// variables named by bounds attributes take their units from their
+	// coordinate, collect them first
+	ck.boundsVars = map[string]bool{}
+	err := g.Walk(func(path string, obj netcdf4.Object) error {
+		v, ok := obj.(netcdf4.Var)
+		if !ok {
+			return nil
+		}
+		b, ok, err := v.Bounds()
+		if err != nil || !ok {
+			return nil
+		}
+		if bPath, err := varPath(b); err == nil {
+			ck.boundsVars[bPath] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.Walk(func(path string, obj netcdf4.Object) error {
+		if v, ok := obj.(netcdf4.Var); ok {
+			return ck.variable(path, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ck.issues, nil
+}
+
+// check holds the state of one Checker.Check call.
+type check struct {
+	table      *StandardNameTable
+	boundsVars map[string]bool
+	issues     []Issue
+}
+
+func (ck *check) report(severity Severity, path, format string, args ...interface{}) {
+	ck.issues = append(ck.issues, Issue{Severity: severity, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func varPath(v netcdf4.Var) (string, error) {
+	dir, err := v.GetParentGroup().Path()
+	if err != nil {
+		return "", err
+	}
+	name, err := v.GetName()
+	if err != nil {
+		return "", err
+	}
+	if dir != "/" {
+		dir += "/"
+	}
+	return dir + name, nil
+}
+
+// textAtt reads a text attribute; ok is false if it is missing.
+func textAtt(v netcdf4.Var, name string) (text string, ok bool, err error) {
+	att, err := v.GetAtt(name)
+	if err != nil {
+		return "", false, nil
+	}
+	text, err = att.Text()
+	return text, true, err
+}
+
+func (ck *check) conventions(g *netcdf4.Group) error {
+	path := "/:Conventions"
+	att, err := g.GetAtt("Conventions")
+	if err != nil {
+		ck.report(Warning, path, "no Conventions attribute, expected e.g. \"CF-1.8\"")
+		return nil
+	}
+	text, err := att.Text()
+	if err != nil {
+		ck.report(Error, path, "Conventions is not text")
+		return nil
+	}
+	for _, f := range strings.FieldsFunc(text, func(r rune) bool { return r == ' ' || r == ',' }) {
+		if strings.HasPrefix(f, "CF-") {
+			return nil
+		}
+	}
+	ck.report(Warning, path, "Conventions %q does not name a CF version", text)
+	return nil
+}
+
+func (ck *check) variable(path string, v netcdf4.Var) error {
+	t, err := v.GetType()
+	if err != nil {
+		return err
+	}
+	numeric := !t.IsNull() && t.GetId() != netcdf4.Char.GetId() && t.GetId() != netcdf4.String.GetId()
+
+	if err := ck.standardName(path, v); err != nil {
+		return err
+	}
+	if err := ck.units(path, v, numeric); err != nil {
+		return err
+	}
+	if err := ck.fillTypes(path, v, t); err != nil {
+		return err
+	}
+	if err := ck.coordinates(path, v); err != nil {
+		return err
+	}
+
+	if _, _, err := v.Bounds(); err != nil {
+		ck.report(Error, path+":bounds", "%v", strings.TrimPrefix(err.Error(), "error: "))
+	}
+	if _, err := v.CellMethods(); err != nil {
+		ck.report(Error, path+":cell_methods", "%v", strings.TrimPrefix(err.Error(), "error: "))
+	}
+	if m, ok, err := v.GridMapping(); err != nil {
+		ck.report(Error, path+":grid_mapping", "%v", strings.TrimPrefix(err.Error(), "error: "))
+	} else if ok {
+		if _, err := m.PROJ(); err != nil {
+			ck.report(Warning, path+":grid_mapping", "%v", strings.TrimPrefix(err.Error(), "error: "))
+		}
+	}
+
+	if numeric {
+		return ck.monotonic(path, v)
+	}
+	return nil
+}
+
+func (ck *check) standardName(path string, v netcdf4.Var) error {
+	value, ok, err := textAtt(v, "standard_name")
+	if !ok {
+		return nil
+	}
+	path += ":standard_name"
+	if err != nil {
+		ck.report(Error, path, "standard_name is not text")
+		return nil
+	}
+	name, known, alias, err := ck.table.Lookup(value)
+	switch {
+	case err != nil:
+		ck.report(Error, path, "%v", err)
+	case alias != "":
+		ck.report(Warning, path, "%q is an alias, use %q", name, alias)
+	case !known && ck.table.Complete:
+		ck.report(Error, path, "%q is not in standard name table version %s", name, ck.table.Version)
+	case !known:
+		ck.report(Error, path, "%q is not in the bundled standard name %s, check it against the full table", name, ck.table.Version)
+	}
+	return nil
+}
+
+func (ck *check) units(path string, v netcdf4.Var, numeric bool) error {
+	text, hasUnits, err := textAtt(v, "units")
+	if err != nil {
+		ck.report(Error, path+":units", "units is not text")
+		return nil
+	}
+	calendar, hasCalendar, err := textAtt(v, "calendar")
+	if err != nil {
+		ck.report(Error, path+":calendar", "calendar is not text")
+		return nil
+	}
+	cal, calErr := netcdf4.ParseCalendar(calendar)
+	if hasCalendar && calErr != nil {
+		ck.report(Error, path+":calendar", "invalid calendar %q", calendar)
+	}
+
+	if !hasUnits {
+		if numeric && !ck.unitless(v, path) {
+			ck.report(Error, path, "no units attribute")
+		}
+		return nil
+	}
+	canonical := ck.canonicalUnits(v)
+	if text == canonical {
+		// such as dBZ, which UDUNITS does not know
+		return nil
+	}
+	if fields := strings.Fields(text); len(fields) > 1 && strings.EqualFold(fields[1], "since") && calErr == nil {
+		if _, err := netcdf4.DecodeTimes(nil, text, cal); err != nil {
+			ck.report(Error, path+":units", "invalid time units %q", text)
+			return nil
+		}
+	}
+	u, err := units.Parse(text)
+	if err != nil {
+		ck.report(Error, path+":units", "invalid units %q", text)
+		return nil
+	}
+	if canonical == "" {
+		return nil
+	}
+	cu, err := units.Parse(canonical)
+	if err != nil {
+		return nil
+	}
+	if !u.ConvertibleTo(cu) && !(u.HasReference() && cu.IsTime()) {
+		ck.report(Error, path+":units", "units %q are not convertible to %q, the canonical units of its standard_name", text, canonical)
+	}
+	return nil
+}
+
+// canonicalUnits returns the canonical units of the standard name of v, ""
+// if it has none in the table.
+func (ck *check) canonicalUnits(v netcdf4.Var) string {
+	value, ok, err := textAtt(v, "standard_name")
+	if !ok || err != nil {
+		return ""
+	}
+	name, known, alias, err := ck.table.Lookup(value)
+	if err != nil || !known {
+		return ""
+	}
+	if alias != "" {
+		name = alias
+	}
+	return ck.table.Units[name]
+}
+
+// unitless returns true for the variables that need no units: bounds,
+// grid mapping, flag and discrete sampling geometry id variables.
+func (ck *check) unitless(v netcdf4.Var, path string) bool {
+	if ck.boundsVars[path] {
+		return true
+	}
+	for _, name := range []string{"grid_mapping_name", "flag_values", "flag_masks", "cf_role", "compress"} {
+		if _, err := v.GetAtt(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (ck *check) fillTypes(path string, v netcdf4.Var, t netcdf4.Type) error {
+	for _, name := range []string{"_FillValue", "missing_value"} {
+		att, err := v.GetAtt(name)
+		if err != nil {
+			continue
+		}
+		at, err := att.GetType()
+		if err != nil {
+			return err
+		}
+		if at.GetId() != t.GetId() {
+			ck.report(Error, path+":"+name, "%s has type %d, the variable has type %d", name, at.GetId(), t.GetId())
+		}
+	}
+	return nil
+}
+
+func (ck *check) coordinates(path string, v netcdf4.Var) error {
+	names, ok, err := textAtt(v, "coordinates")
+	if !ok {
+		return nil
+	}
+	path += ":coordinates"
+	if err != nil {
+		ck.report(Error, path, "coordinates is not text")
+		return nil
+	}
+	for _, name := range strings.Fields(names) {
+		if strings.Contains(name, "/") {
+			if obj, err := v.GetParentGroup().Lookup(name); err == nil {
+				if _, ok := obj.(netcdf4.Var); ok {
+					continue
+				}
+			}
+		} else {
+			c, err := v.GetParentGroup().GetVar(name, netcdf4.ParentsAndCurrent)
+			if err != nil {
+				return err
+			}
+			if !c.IsNull() {
+				continue
+			}
+		}
+		ck.report(Error, path, "no variable %q", name)
+	}
+	return nil
+}
+
+// monotonic checks that a coordinate variable, a 1-D variable named after
+// its dimension, is strictly monotonic and has no missing values.
+func (ck *check) monotonic(path string, v netcdf4.Var) error {
+	dims, err := v.GetDims()
+	if err != nil || len(dims) != 1 {
+		return err
+	}
+	name, err := v.GetName()
+	if err != nil {
+		return err
+	}
+	dimName, err := dims[0].Name()
+	if err != nil || dimName != name {
+		return err
+	}
+
+	values, err := v.ReadUnpacked()
+	if err != nil {
+		ck.report(Warning, path, "coordinate values not checked: %v", strings.TrimPrefix(err.Error(), "error: "))
+		return nil
+	}
+	x := values.Data()
+	for i, xi := range x {
+		if math.IsNaN(xi) {
+			ck.report(Error, path, "coordinate variable has a missing value at index %d", i)
+			return nil
+		}
+	}
+	if len(x) < 2 {
+		return nil
+	}
+	increasing := x[1] > x[0]
+	for i := 1; i < len(x); i++ {
+		if (increasing && !(x[i] > x[i-1])) || (!increasing && !(x[i] < x[i-1])) {
+			ck.report(Error, path, "coordinate variable is not strictly monotonic at index %d", i)
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+//go:build ignore
+
+// gen_table writes standard_names.xml, the table bundled with package
+// cfcheck, from the CF standard name table: the names, their canonical
+// units and the aliases, without the descriptions.
+//
+// Usage:
+//
+//	go run gen_table.go [-src url-or-file] [-o standard_names.xml]
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+const currentTable = "https://cfconventions.org/Data/cf-standard-names/current/src/cf-standard-name-table.xml"
+
+func main() {
+	src := flag.String("src", currentTable, "`url` or file of the CF standard name table")
+	out := flag.String("o", "standard_names.xml", "output `file`")
+	flag.Parse()
+
+	r, err := open(*src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+
+	var doc struct {
+		Version string `xml:"version_number"`
+		Entries []struct {
+			ID    string `xml:"id,attr"`
+			Units string `xml:"canonical_units"`
+		} `xml:"entry"`
+		Aliases []struct {
+			ID      string `xml:"id,attr"`
+			EntryID string `xml:"entry_id"`
+		} `xml:"alias"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		log.Fatalf("reading %s: %v", *src, err)
+	}
+	version := strings.TrimSpace(doc.Version)
+	if version == "" || len(doc.Entries) == 0 {
+		log.Fatalf("%s is not a standard name table", *src)
+	}
+	sort.Slice(doc.Entries, func(i, j int) bool { return doc.Entries[i].ID < doc.Entries[j].ID })
+	sort.Slice(doc.Aliases, func(i, j int) bool { return doc.Aliases[i].ID < doc.Aliases[j].ID })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<?xml version=\"1.0\"?>\n")
+	fmt.Fprintf(&b, "<!-- Code generated by gen_table.go from %s; DO NOT EDIT. -->\n", *src)
+	fmt.Fprintf(&b, "<standard_name_table>\n")
+	fmt.Fprintf(&b, "  <version_number>%s</version_number>\n", escape(version))
+	for _, e := range doc.Entries {
+		fmt.Fprintf(&b, "  <entry id=%q><canonical_units>%s</canonical_units></entry>\n",
+			escape(strings.TrimSpace(e.ID)), escape(strings.TrimSpace(e.Units)))
+	}
+	for _, a := range doc.Aliases {
+		fmt.Fprintf(&b, "  <alias id=%q><entry_id>%s</entry_id></alias>\n",
+			escape(strings.TrimSpace(a.ID)), escape(strings.TrimSpace(a.EntryID)))
+	}
+	fmt.Fprintf(&b, "</standard_name_table>\n")
+	if err := os.WriteFile(*out, []byte(b.String()), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %s: version %s, %d names, %d aliases", *out, version, len(doc.Entries), len(doc.Aliases))
+}
+
+func open(src string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return os.Open(src)
+	}
+	resp, err := http.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s: %s", src, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package cfcheck
+
+import (
+	_ "embed"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// StandardNameTable is a CF standard name table: the standard names with
+// their canonical units, and the aliases of renamed names.
+type StandardNameTable struct {
+	Version string
+	// Units maps each standard name to its canonical units.
+	Units map[string]string
+	// Aliases maps old names to the names that replace them.
+	Aliases map[string]string
+	// Complete is false for a partial table, such as the subset bundled
+	// until the full table is generated; a name that is not found is still
+	// an error, but it may be valid in the full table.
+	Complete bool
+}
+
+// LoadStandardNameTable reads a standard name table in the XML format CF
+// publishes (cf-standard-name-table.xml).
+func LoadStandardNameTable(r io.Reader) (*StandardNameTable, error) {
+	var doc struct {
+		Version string `xml:"version_number"`
+		Entries []struct {
+			ID    string `xml:"id,attr"`
+			Units string `xml:"canonical_units"`
+		} `xml:"entry"`
+		Aliases []struct {
+			ID      string `xml:"id,attr"`
+			EntryID string `xml:"entry_id"`
+		} `xml:"alias"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error: reading standard name table: %v", err)
+	}
+	t := &StandardNameTable{
+		Version:  strings.TrimSpace(doc.Version),
+		Units:    make(map[string]string, len(doc.Entries)),
+		Aliases:  make(map[string]string, len(doc.Aliases)),
+		Complete: true,
+	}
+	for _, e := range doc.Entries {
+		t.Units[strings.TrimSpace(e.ID)] = strings.TrimSpace(e.Units)
+	}
+	for _, a := range doc.Aliases {
+		t.Aliases[strings.TrimSpace(a.ID)] = strings.TrimSpace(a.EntryID)
+	}
+	if len(t.Units) == 0 {
+		return nil, fmt.Errorf("error: standard name table has no entries")
+	}
+	return t, nil
+}
+
+//go:generate go run gen_table.go
+
+// bundledTable is the CF standard name table, as written by gen_table.go.
+// A hand-written subset is marked by the version number "subset".
+//
+//go:embed standard_names.xml
+var bundledTable string
+
+var (
+	bundledOnce sync.Once
+	bundled     *StandardNameTable
+)
+
+// BundledStandardNames returns the table bundled with the package, the full
+// CF table as of its Version, generated with "go generate". It is not
+// Complete if only the hand-written subset of common names is bundled.
+func BundledStandardNames() *StandardNameTable {
+	bundledOnce.Do(func() {
+		t, err := LoadStandardNameTable(strings.NewReader(bundledTable))
+		if err != nil {
+			panic(err)
+		}
+		t.Complete = t.Version != "subset"
+		bundled = t
+	})
+	return bundled
+}
+
+// standardNameModifiers are the modifiers that may follow a standard name.
+var standardNameModifiers = map[string]bool{
+	"detection_minimum":      true,
+	"number_of_observations": true,
+	"standard_error":         true,
+	"status_flag":            true,
+}
+
+// Lookup resolves a standard_name attribute value, which may carry a
+// modifier, to the name in the table. known is false if the name is not in
+// the table; alias is the replacement of a renamed name.
+func (t *StandardNameTable) Lookup(value string) (name string, known bool, alias string, err error) {
+	fields := strings.Fields(value)
+	switch {
+	case len(fields) == 0:
+		return "", false, "", fmt.Errorf("empty standard_name")
+	case len(fields) > 2:
+		return "", false, "", fmt.Errorf("standard_name %q has more than one modifier", value)
+	case len(fields) == 2 && !standardNameModifiers[fields[1]]:
+		return "", false, "", fmt.Errorf("standard_name %q has an unknown modifier %q", value, fields[1])
+	}
+	name = fields[0]
+	if _, ok := t.Units[name]; ok {
+		return name, true, "", nil
+	}
+	if alias, ok := t.Aliases[name]; ok {
+		return name, true, alias, nil
+	}
+	return name, false, "", nil
+}
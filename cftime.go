@@ -0,0 +1,559 @@
+package netcdf4
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Calendar is a CF calendar, the value of the calendar attribute of a time
+// coordinate.
+type Calendar string
+
+const (
+	// Standard is the mixed Julian/Gregorian calendar, switching to the
+	// Gregorian one on 1582-10-15. It is the default.
+	Standard Calendar = "standard"
+	// ProlepticGregorian is the Gregorian calendar extended before 1582, the
+	// calendar of time.Time.
+	ProlepticGregorian Calendar = "proleptic_gregorian"
+	// Julian is the Julian calendar, with a leap year every 4 years.
+	Julian Calendar = "julian"
+	// NoLeap is the 365_day calendar, without leap years.
+	NoLeap Calendar = "noleap"
+	// AllLeap is the 366_day calendar, where every year is a leap year.
+	AllLeap Calendar = "all_leap"
+	// Day360 is the 360_day calendar of twelve 30 day months.
+	Day360 Calendar = "360_day"
+)
+
+// ParseCalendar returns the calendar named name, accepting the CF aliases
+// gregorian, 365_day and 366_day. An empty name is the Standard calendar.
+func ParseCalendar(name string) (Calendar, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "standard", "gregorian":
+		return Standard, nil
+	case "proleptic_gregorian":
+		return ProlepticGregorian, nil
+	case "julian":
+		return Julian, nil
+	case "noleap", "365_day":
+		return NoLeap, nil
+	case "all_leap", "366_day":
+		return AllLeap, nil
+	case "360_day":
+		return Day360, nil
+	default:
+		return "", fmt.Errorf("error: unsupported calendar %q", name)
+	}
+}
+
+// canonical returns the name ParseCalendar gives c, so that aliases such as
+// gregorian compare equal; an unsupported calendar is returned unchanged.
+func (c Calendar) canonical() Calendar {
+	if parsed, err := ParseCalendar(string(c)); err == nil {
+		return parsed
+	}
+	return c
+}
+
+// IsReal returns true for the calendars that describe real dates, Standard,
+// ProlepticGregorian and Julian.
+func (c Calendar) IsReal() bool {
+	c = c.canonical()
+	return c == Standard || c == ProlepticGregorian || c == Julian
+}
+
+var monthDays = [12]int{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// isLeap returns true if year is a leap year in a real calendar.
+func (c Calendar) isLeap(year int) bool {
+	switch {
+	case c == AllLeap:
+		return true
+	case c == NoLeap:
+		return false
+	case c == Julian || (c == Standard && year < 1583):
+		return year%4 == 0
+	default:
+		return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+	}
+}
+
+// daysIn returns the number of days in month of year.
+func (c Calendar) daysIn(year, month int) int {
+	if c == Day360 {
+		return 30
+	}
+	if month == 2 && c.isLeap(year) {
+		return 29
+	}
+	return monthDays[month-1]
+}
+
+// gregorianReform is the Julian day number of 1582-10-15, the first day of
+// the Gregorian part of the Standard calendar.
+const gregorianReform = 2299161
+
+// dayNumber returns a number counting the days of the calendar. For the real
+// calendars it is the Julian day number, so they can be compared.
+func (c Calendar) dayNumber(year, month, day int) int64 {
+	y, m, d := int64(year), int64(month), int64(day)
+	switch c {
+	case Day360:
+		return y*360 + (m-1)*30 + d - 1
+	case NoLeap, AllLeap:
+		n := y * 365
+		if c == AllLeap {
+			n = y * 366
+		}
+		for i := 1; i < month; i++ {
+			n += int64(c.daysIn(year, i))
+		}
+		return n + d - 1
+	}
+	// shift the year to start in March so the leap day comes last
+	a := (14 - m) / 12
+	y, m = y+4800-a, m+12*a-3
+	julian := d + (153*m+2)/5 + 365*y + floorDiv(y, 4) - 32083
+	if c == Julian || (c == Standard && julian < gregorianReform) {
+		return julian
+	}
+	return d + (153*m+2)/5 + 365*y + floorDiv(y, 4) - floorDiv(y, 100) + floorDiv(y, 400) - 32045
+}
+
+// date is the inverse of dayNumber.
+func (c Calendar) date(n int64) (year, month, day int) {
+	switch c {
+	case Day360:
+		year = int(floorDiv(n, 360))
+		r := int(n - int64(year)*360)
+		return year, r/30 + 1, r%30 + 1
+	case NoLeap, AllLeap:
+		length := int64(365)
+		if c == AllLeap {
+			length = 366
+		}
+		year = int(floorDiv(n, length))
+		r := int(n - int64(year)*length)
+		month = 1
+		for r >= c.daysIn(year, month) {
+			r -= c.daysIn(year, month)
+			month++
+		}
+		return year, month, r + 1
+	}
+	var b, cc int64
+	if c == Julian || (c == Standard && n < gregorianReform) {
+		b, cc = 0, n+32082
+	} else {
+		a := n + 32044
+		b = floorDiv(4*a+3, 146097)
+		cc = a - floorDiv(146097*b, 4)
+	}
+	d := floorDiv(4*cc+3, 1461)
+	e := cc - floorDiv(1461*d, 4)
+	m := floorDiv(5*e+2, 153)
+	day = int(e - floorDiv(153*m+2, 5) + 1)
+	month = int(m + 3 - 12*floorDiv(m, 10))
+	year = int(100*b + d - 4800 + floorDiv(m, 10))
+	return year, month, day
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// CFTime is a date and time of day in a CF calendar. Unlike time.Time it can
+// hold dates such as February 30 of the 360_day calendar. The zero value
+// marks a missing time.
+type CFTime struct {
+	Year, Month, Day     int
+	Hour, Minute, Second int
+	Nanosecond           int
+	Calendar             Calendar
+}
+
+// NewCFTime returns the given time in calendar c, which may be given by any
+// name ParseCalendar accepts, or an error if the date does not exist in c.
+func NewCFTime(c Calendar, year, month, day, hour, minute, second, nanosecond int) (CFTime, error) {
+	c, err := ParseCalendar(string(c))
+	if err != nil {
+		return CFTime{}, err
+	}
+	t := CFTime{year, month, day, hour, minute, second, nanosecond, c}
+	if month < 1 || month > 12 || day < 1 || day > c.daysIn(year, month) ||
+		hour < 0 || hour > 23 || minute < 0 || minute > 59 || second < 0 || second > 59 ||
+		nanosecond < 0 || nanosecond >= 1e9 {
+		return CFTime{}, fmt.Errorf("error: %v does not exist in the %s calendar", t, c)
+	}
+	if c == Standard && year == 1582 && month == 10 && day > 4 && day < 15 {
+		return CFTime{}, fmt.Errorf("error: %v falls in the Gregorian reform gap", t)
+	}
+	return t, nil
+}
+
+// CFTimeOf returns t, taken in UTC, as a time of calendar c. For the real
+// calendars the result is the same instant; for the others it has the same
+// year, month, day and time fields, which fails for dates c does not have
+// such as February 29 in the noleap calendar.
+func CFTimeOf(t time.Time, c Calendar) (CFTime, error) {
+	c, err := ParseCalendar(string(c))
+	if err != nil {
+		return CFTime{}, err
+	}
+	t = t.UTC()
+	if c.IsReal() {
+		n := ProlepticGregorian.dayNumber(t.Year(), int(t.Month()), t.Day())
+		year, month, day := c.date(n)
+		return CFTime{year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), c}, nil
+	}
+	return NewCFTime(c, t.Year(), int(t.Month()), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond())
+}
+
+// IsZero returns true for the zero CFTime, which marks a missing time.
+func (t CFTime) IsZero() bool {
+	return t == CFTime{}
+}
+
+// Time converts t to a time.Time in UTC. Times of the real calendars convert
+// to the same instant. For the other calendars the year, month, day and time
+// fields are kept, which fails for dates Go does not know such as February
+// 30.
+func (t CFTime) Time() (time.Time, error) {
+	if t.IsZero() {
+		return time.Time{}, fmt.Errorf("error: missing time")
+	}
+	year, month, day := t.Year, t.Month, t.Day
+	if c := t.Calendar.canonical(); c.IsReal() {
+		year, month, day = ProlepticGregorian.date(c.dayNumber(year, month, day))
+	} else if day > ProlepticGregorian.daysIn(year, month) {
+		return time.Time{}, fmt.Errorf("error: %v of the %s calendar is not a valid date", t, t.Calendar)
+	}
+	return time.Date(year, time.Month(month), day, t.Hour, t.Minute, t.Second, t.Nanosecond, time.UTC), nil
+}
+
+// Sub returns the duration t-u. Both must be in the same calendar.
+func (t CFTime) Sub(u CFTime) (time.Duration, error) {
+	c := t.Calendar.canonical()
+	if c != u.Calendar.canonical() {
+		return 0, fmt.Errorf("error: cannot subtract times of the %s and %s calendars", t.Calendar, u.Calendar)
+	}
+	days := c.dayNumber(t.Year, t.Month, t.Day) - c.dayNumber(u.Year, u.Month, u.Day)
+	return time.Duration(days)*24*time.Hour + t.clock() - u.clock(), nil
+}
+
+// Add returns t+d.
+func (t CFTime) Add(d time.Duration) CFTime {
+	c := t.Calendar.canonical()
+	n := c.dayNumber(t.Year, t.Month, t.Day)
+	clock := t.clock() + d
+	days := floorDiv(int64(clock), int64(24*time.Hour))
+	clock -= time.Duration(days) * 24 * time.Hour
+	return c.fromDay(n+days, clock)
+}
+
+// clock returns the time of day as a duration.
+func (t CFTime) clock() time.Duration {
+	return time.Duration(t.Hour)*time.Hour + time.Duration(t.Minute)*time.Minute +
+		time.Duration(t.Second)*time.Second + time.Duration(t.Nanosecond)
+}
+
+// fromDay returns the time clock into day n of the calendar.
+func (c Calendar) fromDay(n int64, clock time.Duration) CFTime {
+	year, month, day := c.date(n)
+	return CFTime{
+		Year: year, Month: month, Day: day,
+		Hour:       int(clock / time.Hour),
+		Minute:     int(clock % time.Hour / time.Minute),
+		Second:     int(clock % time.Minute / time.Second),
+		Nanosecond: int(clock % time.Second),
+		Calendar:   c,
+	}
+}
+
+// String formats t as in "2000-02-30 12:00:00".
+func (t CFTime) String() string {
+	s := fmt.Sprintf("%04d-%02d-%02d %02d:%02d:%02d", t.Year, t.Month, t.Day, t.Hour, t.Minute, t.Second)
+	if t.Nanosecond != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%09d", t.Nanosecond), "0")
+	}
+	return s
+}
+
+// timeUnits is a parsed CF time unit such as "hours since 1900-01-01".
+type timeUnits struct {
+	unit float64 // the length of one unit in seconds
+	ref  CFTime
+	// day and clock are the day number and time of day of ref
+	day   int64
+	clock time.Duration
+}
+
+// parseTimeUnits parses units of the form "<unit> since <reference time>"
+// in calendar c. The unit is one of days, hours, minutes, seconds,
+// milliseconds or microseconds; months and years are accepted as the
+// UDUNITS lengths, or 30 and 360 days in the 360_day calendar.
+func parseTimeUnits(units string, c Calendar) (timeUnits, error) {
+	fields := strings.Fields(units)
+	if len(fields) < 3 || strings.ToLower(fields[1]) != "since" {
+		return timeUnits{}, fmt.Errorf("error: %q are not time units", units)
+	}
+	var tu timeUnits
+	const day = 86400
+	year := 365.242198781 * day
+	if c == Day360 {
+		year = 360 * day
+	}
+	switch strings.ToLower(fields[0]) {
+	case "days", "day", "d":
+		tu.unit = day
+	case "hours", "hour", "hrs", "hr", "h":
+		tu.unit = 3600
+	case "minutes", "minute", "mins", "min":
+		tu.unit = 60
+	case "seconds", "second", "secs", "sec", "s":
+		tu.unit = 1
+	case "milliseconds", "millisecond", "msecs", "msec", "ms":
+		tu.unit = 1e-3
+	case "microseconds", "microsecond", "usecs", "usec", "us":
+		tu.unit = 1e-6
+	case "months", "month":
+		tu.unit = year / 12
+	case "years", "year", "yr":
+		tu.unit = year
+	default:
+		return timeUnits{}, fmt.Errorf("error: unsupported time unit %q in %q", fields[0], units)
+	}
+
+	ref, offset, err := parseRefTime(strings.Join(fields[2:], " "), c)
+	if err != nil {
+		return timeUnits{}, fmt.Errorf("error: bad reference time in %q: %v", units, err)
+	}
+	tu.ref = ref.Add(-offset)
+	tu.day = c.dayNumber(tu.ref.Year, tu.ref.Month, tu.ref.Day)
+	tu.clock = tu.ref.clock()
+	return tu, nil
+}
+
+// parseRefTime parses a reference time such as "1900-1-1", "1900-01-01
+// 00:00:0.0", "1970-01-01 00", "2000-01-01T06:00:00Z" or "1970-01-01
+// 00:00:00 -6:00" and returns it with its time zone offset.
+func parseRefTime(s string, c Calendar) (CFTime, time.Duration, error) {
+	s = strings.TrimSpace(s)
+	// the T of ISO 8601 separating date and time
+	if i := strings.IndexByte(s, 'T'); i > 0 && i+1 < len(s) && isDigit(s[i-1]) && isDigit(s[i+1]) {
+		s = s[:i] + " " + s[i+1:]
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return CFTime{}, 0, fmt.Errorf("empty")
+	}
+
+	negYear := strings.HasPrefix(fields[0], "-")
+	ymd := strings.Split(strings.TrimPrefix(fields[0], "-"), "-")
+	if len(ymd) != 3 {
+		return CFTime{}, 0, fmt.Errorf("date %q is not year-month-day", fields[0])
+	}
+	var date [3]int
+	for i, f := range ymd {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return CFTime{}, 0, fmt.Errorf("date %q is not year-month-day", fields[0])
+		}
+		date[i] = n
+	}
+	if negYear {
+		date[0] = -date[0]
+	}
+
+	var hms [3]int
+	var nanos int
+	var offset time.Duration
+	rest := fields[1:]
+	// a time of day, possibly only the hour as in "1970-01-01 00"
+	if len(rest) > 0 && isDigit(rest[0][0]) {
+		clock := rest[0]
+		rest = rest[1:]
+		// a zone may be attached to the clock
+		for _, sep := range []string{"Z", "+", "-"} {
+			if i := strings.Index(clock, sep); i > 0 {
+				rest = append([]string{clock[i:]}, rest...)
+				clock = clock[:i]
+				break
+			}
+		}
+		parts := strings.Split(clock, ":")
+		if len(parts) > 3 {
+			return CFTime{}, 0, fmt.Errorf("bad time of day %q", clock)
+		}
+		for i, p := range parts {
+			if i == 2 {
+				sec, err := strconv.ParseFloat(p, 64)
+				if err != nil {
+					return CFTime{}, 0, fmt.Errorf("bad time of day %q", clock)
+				}
+				hms[2] = int(sec)
+				nanos = int(math.Round((sec - math.Floor(sec)) * 1e9))
+				continue
+			}
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return CFTime{}, 0, fmt.Errorf("bad time of day %q", clock)
+			}
+			hms[i] = n
+		}
+	}
+	if len(rest) > 0 {
+		var err error
+		if offset, err = parseZone(strings.Join(rest, "")); err != nil {
+			return CFTime{}, 0, err
+		}
+	}
+	t, err := NewCFTime(c, date[0], date[1], date[2], hms[0], hms[1], hms[2], nanos)
+	return t, offset, err
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+// parseZone parses a time zone: Z, UTC, GMT or an offset like +05:30,
+// -6 or +0530.
+func parseZone(zone string) (time.Duration, error) {
+	switch strings.ToUpper(zone) {
+	case "Z", "UTC", "GMT":
+		return 0, nil
+	}
+	if len(zone) < 2 || (zone[0] != '+' && zone[0] != '-') {
+		return 0, fmt.Errorf("bad time zone %q", zone)
+	}
+	hh, mm, found := strings.Cut(zone[1:], ":")
+	if !found && len(hh) == 4 {
+		hh, mm = hh[:2], hh[2:]
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil {
+		return 0, fmt.Errorf("bad time zone %q", zone)
+	}
+	m := 0
+	if mm != "" {
+		if m, err = strconv.Atoi(mm); err != nil {
+			return 0, fmt.Errorf("bad time zone %q", zone)
+		}
+	}
+	offset := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+	if zone[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// DecodeTimes converts values in CF time units such as "days since
+// 2000-01-01" to times of calendar c. NaN values give the zero CFTime.
+// Times are rounded to the microsecond.
+func DecodeTimes(values []float64, units string, c Calendar) ([]CFTime, error) {
+	c, err := ParseCalendar(string(c))
+	if err != nil {
+		return nil, err
+	}
+	tu, err := parseTimeUnits(units, c)
+	if err != nil {
+		return nil, err
+	}
+	times := make([]CFTime, len(values))
+	for i, x := range values {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			continue
+		}
+		secs := x * tu.unit
+		days := math.Floor(secs / 86400)
+		micros := math.Round((secs - days*86400) * 1e6)
+		clock := tu.clock + time.Duration(micros)*time.Microsecond
+		extra := floorDiv(int64(clock), int64(24*time.Hour))
+		clock -= time.Duration(extra) * 24 * time.Hour
+		times[i] = c.fromDay(tu.day+int64(days)+extra, clock)
+	}
+	return times, nil
+}
+
+// EncodeTimes converts times, which must be of calendar c or one of its
+// aliases, to values in CF time units. Zero times give NaN.
+func EncodeTimes(times []CFTime, units string, c Calendar) ([]float64, error) {
+	c, err := ParseCalendar(string(c))
+	if err != nil {
+		return nil, err
+	}
+	tu, err := parseTimeUnits(units, c)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, len(times))
+	for i, t := range times {
+		if t.IsZero() {
+			values[i] = math.NaN()
+			continue
+		}
+		if t.Calendar.canonical() != c {
+			return nil, fmt.Errorf("error: time %v of the %s calendar in a %s time axis", t, t.Calendar, c)
+		}
+		days := c.dayNumber(t.Year, t.Month, t.Day) - tu.day
+		secs := float64(days)*86400 + (t.clock() - tu.clock).Seconds()
+		values[i] = secs / tu.unit
+	}
+	return values, nil
+}
+
+// TimeUnits returns the units and calendar attributes of a time variable.
+func (v Var) TimeUnits() (string, Calendar, error) {
+	att, err := v.GetAtt("units")
+	if err != nil {
+		return "", "", err
+	}
+	units, err := att.Text()
+	if err != nil {
+		return "", "", err
+	}
+	name := ""
+	if att, err := v.GetAtt("calendar"); err == nil {
+		if name, err = att.Text(); err != nil {
+			return "", "", err
+		}
+	}
+	c, err := ParseCalendar(name)
+	return units, c, err
+}
+
+// Times reads a time variable and decodes its values with its units and
+// calendar attributes. Missing values give the zero CFTime.
+func (v Var) Times() ([]CFTime, error) {
+	units, c, err := v.TimeUnits()
+	if err != nil {
+		return nil, err
+	}
+	values, err := v.ReadUnpacked()
+	if err != nil {
+		return nil, err
+	}
+	return DecodeTimes(values.Data(), units, c)
+}
+
+// PutTimes encodes times with the units and calendar attributes of the
+// variable and writes them as its whole data.
+func (v Var) PutTimes(times []CFTime) error {
+	units, c, err := v.TimeUnits()
+	if err != nil {
+		return err
+	}
+	values, err := EncodeTimes(times, units, c)
+	if err != nil {
+		return err
+	}
+	return v.PutPacked(values)
+}
@@ -0,0 +1,242 @@
+package netcdf4
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var calendars = []Calendar{Standard, ProlepticGregorian, Julian, NoLeap, AllLeap, Day360}
+
+func TestParseCalendar(t *testing.T) {
+	tests := []struct {
+		name string
+		want Calendar
+	}{
+		{"", Standard},
+		{"standard", Standard},
+		{"Gregorian", Standard},
+		{"proleptic_gregorian", ProlepticGregorian},
+		{"julian", Julian},
+		{"noleap", NoLeap},
+		{"365_day", NoLeap},
+		{"all_leap", AllLeap},
+		{"366_day", AllLeap},
+		{" 360_day ", Day360},
+	}
+	for _, tt := range tests {
+		if got, err := ParseCalendar(tt.name); err != nil || got != tt.want {
+			t.Errorf("ParseCalendar(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+	if _, err := ParseCalendar("lunar"); err == nil {
+		t.Error("ParseCalendar(lunar) did not fail")
+	}
+}
+
+func TestDayNumberRoundTrip(t *testing.T) {
+	for _, c := range calendars {
+		start := c.dayNumber(-4000, 1, 1)
+		end := c.dayNumber(4000, 12, 31)
+		prev := start - 1
+		for n := start; n <= end; n += 17 {
+			year, month, day := c.date(n)
+			if got := c.dayNumber(year, month, day); got != n {
+				t.Fatalf("%s: day %d is %04d-%02d-%02d, which is day %d", c, n, year, month, day, got)
+			}
+			if day < 1 || day > c.daysIn(year, month) || month < 1 || month > 12 {
+				t.Fatalf("%s: day %d is %04d-%02d-%02d", c, n, year, month, day)
+			}
+			if n <= prev {
+				t.Fatalf("%s: day numbers not increasing at %d", c, n)
+			}
+			prev = n
+		}
+	}
+}
+
+func TestJulianDayNumbers(t *testing.T) {
+	tests := []struct {
+		c                Calendar
+		year, month, day int
+		want             int64
+	}{
+		{Standard, 2000, 1, 1, 2451545},
+		{ProlepticGregorian, 2000, 1, 1, 2451545},
+		{Standard, 1582, 10, 15, gregorianReform},
+		{Standard, 1582, 10, 4, gregorianReform - 1},
+		{Julian, 1582, 10, 5, gregorianReform},
+		{ProlepticGregorian, 1582, 10, 15, gregorianReform},
+		{Standard, -4712, 1, 1, 0},
+		{Julian, -4712, 1, 1, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.c.dayNumber(tt.year, tt.month, tt.day); got != tt.want {
+			t.Errorf("%s %04d-%02d-%02d: day %d, want %d", tt.c, tt.year, tt.month, tt.day, got, tt.want)
+		}
+	}
+}
+
+func TestGregorianReform(t *testing.T) {
+	before, err := NewCFTime(Standard, 1582, 10, 4, 12, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := before.Add(24 * time.Hour)
+	if after.Year != 1582 || after.Month != 10 || after.Day != 15 {
+		t.Errorf("the day after %v is %v", before, after)
+	}
+	if d, err := after.Sub(before); err != nil || d != 24*time.Hour {
+		t.Errorf("%v - %v = %v, %v", after, before, d, err)
+	}
+	if _, err := NewCFTime(Standard, 1582, 10, 10, 0, 0, 0, 0); err == nil {
+		t.Error("1582-10-10 exists in the standard calendar")
+	}
+	for _, c := range []Calendar{Julian, ProlepticGregorian} {
+		if _, err := NewCFTime(c, 1582, 10, 10, 0, 0, 0, 0); err != nil {
+			t.Errorf("1582-10-10 of the %s calendar: %v", c, err)
+		}
+	}
+	// 1500 is a leap year before the reform only
+	if _, err := NewCFTime(Standard, 1500, 2, 29, 0, 0, 0, 0); err != nil {
+		t.Error(err)
+	}
+	if _, err := NewCFTime(ProlepticGregorian, 1500, 2, 29, 0, 0, 0, 0); err == nil {
+		t.Error("1500-02-29 exists in the proleptic_gregorian calendar")
+	}
+	times, err := DecodeTimes([]float64{1}, "days since 1582-10-04", Standard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := times[0].String(); got != "1582-10-15 00:00:00" {
+		t.Errorf("1 day since 1582-10-04 is %s", got)
+	}
+}
+
+func TestDecodeTimes(t *testing.T) {
+	tests := []struct {
+		value float64
+		units string
+		c     Calendar
+		want  string
+	}{
+		{0, "days since 1970-01-01", Standard, "1970-01-01 00:00:00"},
+		{1.5, "days since 1970-01-01", Standard, "1970-01-02 12:00:00"},
+		{-1, "hours since 2000-01-01", ProlepticGregorian, "1999-12-31 23:00:00"},
+		{0, "hours since 1970-01-01 00:00:00 +01:00", Standard, "1969-12-31 23:00:00"},
+		{0, "hours since 1970-01-01T06:00:00Z", Standard, "1970-01-01 06:00:00"},
+		{0, "hours since 1970-01-01 00:00:00 -0530", Standard, "1970-01-01 05:30:00"},
+		{0, "hours since 1970-01-01 6:00 -6", Standard, "1970-01-01 12:00:00"},
+		{0, "days since 1970-01-01 00", Standard, "1970-01-01 00:00:00"},
+		{0, "days since 1970-01-01 06", Standard, "1970-01-01 06:00:00"},
+		{0, "days since 1970-01-01 06Z", Standard, "1970-01-01 06:00:00"},
+		{0.5, "seconds since 2000-01-01 00:00:00.25", Standard, "2000-01-01 00:00:00.75"},
+		{59, "days since 2000-01-01", Day360, "2000-02-30 00:00:00"},
+		{1, "years since 2000-01-01", Day360, "2001-01-01 00:00:00"},
+		{1, "months since 2000-01-01", Day360, "2000-02-01 00:00:00"},
+		{365, "days since 2000-01-01", NoLeap, "2001-01-01 00:00:00"},
+		{59, "days since 2001-01-01", AllLeap, "2001-02-29 00:00:00"},
+		{10, "days since 1900-02-25", Julian, "1900-03-06 00:00:00"},
+		{10, "days since 1900-02-25", Standard, "1900-03-07 00:00:00"},
+		{0, "days since 1970-01-01", "gregorian", "1970-01-01 00:00:00"},
+	}
+	for _, tt := range tests {
+		times, err := DecodeTimes([]float64{tt.value}, tt.units, tt.c)
+		if err != nil {
+			t.Errorf("DecodeTimes(%v, %q, %s): %v", tt.value, tt.units, tt.c, err)
+			continue
+		}
+		if got := times[0].String(); got != tt.want {
+			t.Errorf("DecodeTimes(%v, %q, %s) = %s, want %s", tt.value, tt.units, tt.c, got, tt.want)
+		}
+	}
+
+	times, err := DecodeTimes([]float64{math.NaN()}, "days since 2000-01-01", Standard)
+	if err != nil || !times[0].IsZero() {
+		t.Errorf("DecodeTimes(NaN) = %v, %v", times, err)
+	}
+	for _, units := range []string{"days", "days after 2000-01-01", "fortnights since 2000-01-01",
+		"days since 2000-13-01", "days since 2000-01-01 00:00:00 bogus", "days since 2000-02-30"} {
+		if _, err := DecodeTimes(nil, units, Standard); err == nil {
+			t.Errorf("DecodeTimes with units %q did not fail", units)
+		}
+	}
+}
+
+func TestEncodeTimesRoundTrip(t *testing.T) {
+	const units = "hours since 1850-01-01 00:00:00"
+	for _, c := range calendars {
+		values := make([]float64, 0, 1000)
+		for h := -200000.0; h < 2000000; h += 2111 {
+			values = append(values, h)
+		}
+		times, err := DecodeTimes(values, units, c)
+		if err != nil {
+			t.Fatalf("%s: %v", c, err)
+		}
+		back, err := EncodeTimes(times, units, c)
+		if err != nil {
+			t.Fatalf("%s: %v", c, err)
+		}
+		for i := range values {
+			if math.Abs(back[i]-values[i]) > 1e-9 {
+				t.Fatalf("%s: %v %s is %v, encoded as %v", c, values[i], units, times[i], back[i])
+			}
+		}
+	}
+}
+
+func TestEncodeTimesCalendarAliases(t *testing.T) {
+	tests := []struct {
+		timeCalendar, axisCalendar Calendar
+	}{
+		{"gregorian", Standard},
+		{Standard, "gregorian"},
+		{"365_day", NoLeap},
+		{"366_day", AllLeap},
+	}
+	for _, tt := range tests {
+		tm := CFTime{Year: 2000, Month: 1, Day: 2, Calendar: tt.timeCalendar}
+		values, err := EncodeTimes([]CFTime{tm}, "days since 2000-01-01", tt.axisCalendar)
+		if err != nil {
+			t.Errorf("%s time in a %s axis: %v", tt.timeCalendar, tt.axisCalendar, err)
+			continue
+		}
+		if values[0] != 1 {
+			t.Errorf("%s time in a %s axis: %v, want 1", tt.timeCalendar, tt.axisCalendar, values[0])
+		}
+	}
+	tm := CFTime{Year: 2000, Month: 1, Day: 2, Calendar: NoLeap}
+	if _, err := EncodeTimes([]CFTime{tm}, "days since 2000-01-01", Standard); err == nil {
+		t.Error("encoded a noleap time in a standard axis")
+	}
+}
+
+func TestCFTimeConversions(t *testing.T) {
+	tm := time.Date(1000, 3, 1, 12, 30, 0, 0, time.UTC)
+	ct, err := CFTimeOf(tm, Standard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1000-03-01 proleptic Gregorian is 1000-02-24 Julian
+	if got := ct.String(); got != "1000-02-24 12:30:00" {
+		t.Errorf("CFTimeOf(%v, standard) = %s", tm, got)
+	}
+	back, err := ct.Time()
+	if err != nil || !back.Equal(tm) {
+		t.Errorf("%v.Time() = %v, %v", ct, back, err)
+	}
+	if _, err := CFTimeOf(time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC), NoLeap); err == nil {
+		t.Error("2000-02-29 exists in the noleap calendar")
+	}
+	ct, err = NewCFTime(Day360, 2000, 2, 30, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ct.Time(); err == nil {
+		t.Error("converted 2000-02-30 to a time.Time")
+	}
+	if _, err := ct.Sub(CFTime{Year: 2000, Month: 1, Day: 1, Calendar: Standard}); err == nil {
+		t.Error("subtracted times of different calendars")
+	}
+}
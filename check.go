@@ -1,5 +1,8 @@
 package netcdf4
 
+// #include <netcdf.h>
+import "C"
+
 // CheckDefineMode checks if the file (group) is in define mode.
 // If not, it places it in the define mode.
 // While this is automatically done by the underlying C API
@@ -12,13 +15,14 @@ func CheckDefineMode(ncid ID) error {
 	return nil
 }
 
-// Function checks if the file (group) is in data mode.
+// CheckDataMode checks if the file (group) is in data mode.
 // If not, it places it in the data mode.
 // While this is automatically done by the underlying C API
 // for netCDF-4 files, the netCDF-3 files still need this call.
-
-//void CheckDataMode(int ncid, string context /* = "" */)
-//{
-//int status = nc_enddef(ncid);
-//if (status != NC_ENOTINDEFINE) ncxxCheck(status, __FILE__, __LINE__);
-//}
+func CheckDataMode(ncid ID) error {
+	err := ncEnddef(ncid)
+	if err != nil && err != Error(C.NC_ENOTINDEFINE) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Command cfcheck reports CF conventions issues in netCDF files.
+//
+// Usage:
+//
+//	cfcheck [-table cf-standard-name-table.xml] [-q] [-strict] file.nc...
+//
+// It prints one line per issue and exits with status 1 if any file has an
+// error, or with -strict any issue at all, 2 if a file cannot be read.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/NCAR/netcdf4-go"
+	"github.com/NCAR/netcdf4-go/cfcheck"
+)
+
+func main() {
+	tablePath := flag.String("table", "", "CF standard name table `xml` file; by default the bundled table is used, which may be only a subset")
+	quiet := flag.Bool("q", false, "report errors only, not warnings")
+	strict := flag.Bool("strict", false, "exit with status 1 on warnings as well as errors")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: cfcheck [flags] file.nc...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	checker := &cfcheck.Checker{}
+	if *tablePath != "" {
+		r, err := os.Open(*tablePath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		checker.StandardNames, err = cfcheck.LoadStandardNameTable(r)
+		r.Close()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	status := 0
+	for _, path := range flag.Args() {
+		issues, err := checkFile(checker, path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			status = 2
+			continue
+		}
+		for _, issue := range issues {
+			if *quiet && issue.Severity != cfcheck.Error {
+				continue
+			}
+			fmt.Printf("%s: %v\n", path, issue)
+		}
+		if (cfcheck.HasErrors(issues) || *strict && len(issues) > 0) && status == 0 {
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+func checkFile(checker *cfcheck.Checker, path string) ([]cfcheck.Issue, error) {
+	file := netcdf4.NewFile()
+	if err := file.Open(path, netcdf4.READ, netcdf4.UNKNOWN); err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return checker.Check(file.Group)
+}
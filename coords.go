@@ -0,0 +1,270 @@
+package netcdf4
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Axis is a CF coordinate axis.
+type Axis int
+
+// Known axes
+const (
+	AxisX Axis = iota // Longitude or projection x coordinate.
+	AxisY             // Latitude or projection y coordinate.
+	AxisZ             // Vertical coordinate.
+	AxisT             // Time coordinate.
+)
+
+// String conforms to fmt.Stringer interface
+func (a Axis) String() string {
+	if a < 0 || a > AxisT {
+		return "Axis(" + strconv.FormatInt(int64(a), 10) + ")"
+	}
+	return "XYZT"[a : a+1]
+}
+
+// Coordinates gets the coordinate variables of the dimensions of the
+// variable, in dimension order, followed by the auxiliary coordinate
+// variables named in its 'coordinates' attribute. Names in the attribute are
+// searched for in the variable's group and then in its parents, or resolved
+// as paths if they contain a '/'; names that cannot be resolved are skipped.
+func (v Var) Coordinates() ([]Var, error) {
+	if v.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke Coordinates on a Null variable")
+	}
+	var coords []Var
+	seen := map[[2]ID]bool{}
+	add := func(c Var) {
+		key := [2]ID{c.groupId, c.myId}
+		if !seen[key] {
+			seen[key] = true
+			coords = append(coords, c)
+		}
+	}
+
+	dims, err := v.GetDims()
+	if err != nil {
+		return nil, err
+	}
+	for _, dim := range dims {
+		c, ok, err := coordinateVar(dim)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			add(c)
+		}
+	}
+
+	att, err := v.GetAtt("coordinates")
+	if err != nil {
+		return coords, nil
+	}
+	names, err := att.Text()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Fields(names) {
+		if c, ok := findVar(v.GetParentGroup(), name); ok {
+			add(c)
+		}
+	}
+	return coords, nil
+}
+
+// findVar finds the variable name in grp or its parents, or at the path
+// name relative to grp if it contains a '/'.
+func findVar(grp *Group, name string) (Var, bool) {
+	if strings.Contains(name, "/") {
+		obj, err := grp.Lookup(name)
+		if v, ok := obj.(Var); err == nil && ok {
+			return v, true
+		}
+		return NewVarNull(), false
+	}
+	for ; grp != nil; grp = grp.GetParentGroup() {
+		if varID, err := ncInqVarid(grp.id, name); err == nil {
+			return NewVar(*grp, varID), true
+		}
+	}
+	return NewVarNull(), false
+}
+
+// isCoordinateVar returns true if v is the coordinate variable of its single
+// dimension, that is it has the dimension's name.
+func (v Var) isCoordinateVar() (bool, error) {
+	dims, err := v.GetDims()
+	if err != nil || len(dims) != 1 {
+		return false, err
+	}
+	name, err := v.GetName()
+	if err != nil {
+		return false, err
+	}
+	dimName, err := dims[0].Name()
+	return name == dimName, err
+}
+
+// Axis identifies the CF axis of a coordinate variable from, in order of
+// precedence, its 'axis' attribute, its 'standard_name', the
+// '_CoordinateAxisType' attribute some producers write, its 'units' and its
+// 'positive' attribute. ok is false if it is none of X, Y, Z or T.
+func (v Var) Axis() (axis Axis, ok bool, err error) {
+	if v.IsNull() {
+		return 0, false, fmt.Errorf("error: attempt to invoke Axis on a Null variable")
+	}
+	text := func(name string) (string, error) {
+		att, err := v.GetAtt(name)
+		if err != nil {
+			return "", nil
+		}
+		s, err := att.Text()
+		return strings.TrimSpace(s), err
+	}
+
+	value, err := text("axis")
+	if err != nil {
+		return 0, false, err
+	}
+	switch strings.ToUpper(value) {
+	case "X":
+		return AxisX, true, nil
+	case "Y":
+		return AxisY, true, nil
+	case "Z":
+		return AxisZ, true, nil
+	case "T":
+		return AxisT, true, nil
+	}
+
+	if value, err = text("standard_name"); err != nil {
+		return 0, false, err
+	}
+	if axis, ok := standardNameAxis(value); ok {
+		return axis, true, nil
+	}
+
+	if value, err = text("_CoordinateAxisType"); err != nil {
+		return 0, false, err
+	}
+	switch value {
+	case "Lon", "GeoX":
+		return AxisX, true, nil
+	case "Lat", "GeoY":
+		return AxisY, true, nil
+	case "Height", "Pressure", "GeoZ":
+		return AxisZ, true, nil
+	case "Time":
+		return AxisT, true, nil
+	}
+
+	if value, err = text("units"); err != nil {
+		return 0, false, err
+	}
+	if axis, ok := unitsAxis(value); ok {
+		return axis, true, nil
+	}
+
+	if value, err = text("positive"); err != nil {
+		return 0, false, err
+	}
+	if p := strings.ToLower(value); p == "up" || p == "down" {
+		return AxisZ, true, nil
+	}
+	return 0, false, nil
+}
+
+// standardNameAxis returns the axis a coordinate standard name implies.
+func standardNameAxis(name string) (Axis, bool) {
+	switch name {
+	case "longitude", "grid_longitude", "projection_x_coordinate", "projection_x_angular_coordinate":
+		return AxisX, true
+	case "latitude", "grid_latitude", "projection_y_coordinate", "projection_y_angular_coordinate":
+		return AxisY, true
+	case "time", "forecast_reference_time":
+		return AxisT, true
+	case "altitude", "height", "height_above_geopotential_datum", "height_above_reference_ellipsoid",
+		"height_above_mean_sea_level", "depth", "depth_below_geoid", "depth_below_sea_floor",
+		"air_pressure", "geopotential_height", "model_level_number", "sea_water_pressure":
+		return AxisZ, true
+	}
+	// dimensionless vertical coordinates such as
+	// atmosphere_hybrid_sigma_pressure_coordinate or ocean_sigma_coordinate
+	if strings.HasSuffix(name, "_coordinate") &&
+		(strings.HasPrefix(name, "atmosphere_") || strings.HasPrefix(name, "ocean_")) {
+		return AxisZ, true
+	}
+	return 0, false
+}
+
+// unitsAxis returns the axis that units imply: latitude and longitude units,
+// time units with a reference time and pressure units.
+func unitsAxis(units string) (Axis, bool) {
+	switch units {
+	case "degrees_east", "degree_east", "degree_E", "degrees_E", "degreeE", "degreesE":
+		return AxisX, true
+	case "degrees_north", "degree_north", "degree_N", "degrees_N", "degreeN", "degreesN":
+		return AxisY, true
+	case "Pa", "hPa", "kPa", "mbar", "millibar", "bar", "dbar", "decibar", "atm":
+		return AxisZ, true
+	}
+	if fields := strings.Fields(units); len(fields) >= 3 && strings.ToLower(fields[1]) == "since" {
+		return AxisT, true
+	}
+	return 0, false
+}
+
+// Axis gets the coordinate variables in location that Var.Axis identifies as
+// axis. Candidates are the coordinate variables, the variables named in the
+// 'coordinates' attribute of others and the variables with an 'axis'
+// attribute.
+func (g *Group) Axis(axis Axis, location Location) ([]Var, error) {
+	if g.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke Axis on a Null group")
+	}
+	ncVars, err := g.GetVarsM(location)
+	if err != nil {
+		return nil, err
+	}
+
+	// collect the candidates in the order they were found
+	var candidates []Var
+	seen := map[[2]ID]bool{}
+	add := func(v Var) {
+		key := [2]ID{v.groupId, v.myId}
+		if !seen[key] {
+			seen[key] = true
+			candidates = append(candidates, v)
+		}
+	}
+	for _, v := range ncVars.Values() {
+		isCoord, err := v.isCoordinateVar()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := v.GetAtt("axis"); isCoord || err == nil {
+			add(v)
+		}
+		coords, err := v.Coordinates()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range coords {
+			add(c)
+		}
+	}
+
+	var found []Var
+	for _, v := range candidates {
+		a, ok, err := v.Axis()
+		if err != nil {
+			return nil, err
+		}
+		if ok && a == axis {
+			found = append(found, v)
+		}
+	}
+	return found, nil
+}
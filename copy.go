@@ -0,0 +1,490 @@
+package netcdf4
+
+// #include <netcdf.h>
+// #include <netcdf_filter.h>
+import "C"
+import "fmt"
+
+const defaultCopyBufferSize = 16 << 20
+
+// CopyOption configures Var.CopyTo and Group.CopyTo.
+type CopyOption func(*copyConfig)
+
+type copyConfig struct {
+	name        string // new name, Var.CopyTo only
+	noData      bool
+	noStorage   bool
+	bufferSize  int
+	skip        Var // variable left out, used by File.RemoveVar
+	srcFormat   FileFormat
+	dstFormat   FileFormat
+	haveFormats bool
+}
+
+// CopyName gives the copy of a variable a new name.
+func CopyName(name string) CopyOption {
+	return func(c *copyConfig) { c.name = name }
+}
+
+// CopyWithoutData copies definitions and attributes but no data.
+func CopyWithoutData() CopyOption {
+	return func(c *copyConfig) { c.noData = true }
+}
+
+// CopyWithoutStorage leaves chunking, compression, checksums, endianness and
+// fill mode of the copies to the library defaults.
+func CopyWithoutStorage() CopyOption {
+	return func(c *copyConfig) { c.noStorage = true }
+}
+
+// CopyBufferSize bounds the memory, in bytes, used to stream the data of one
+// variable; the default is 16 MiB. At least one value is always read at a time.
+func CopyBufferSize(size int) CopyOption {
+	return func(c *copyConfig) { c.bufferSize = size }
+}
+
+func newCopyConfig(opts []CopyOption) *copyConfig {
+	c := &copyConfig{bufferSize: defaultCopyBufferSize, skip: NewVarNull()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// storageSupported returns true if both files can hold chunking and filters,
+// i.e. both are HDF5 based.
+func (c *copyConfig) storageSupported(src ID, dst ID) (bool, error) {
+	if !c.haveFormats {
+		var err error
+		if c.srcFormat, err = ncInqFormat(src); err != nil {
+			return false, err
+		}
+		if c.dstFormat, err = ncInqFormat(dst); err != nil {
+			return false, err
+		}
+		c.haveFormats = true
+	}
+	isHDF5 := func(f FileFormat) bool { return f == NETCDF4 || f == NETCDF4CLASSIC }
+	return isHDF5(c.srcFormat) && isHDF5(c.dstFormat), nil
+}
+
+// CopyTo recreates the variable in dstGroup, which usually belongs to another
+// File, and returns the copy. Dimensions the variable uses are looked up by
+// name in dstGroup and its parents and created in dstGroup when missing; a
+// dimension found with a different size is an error. User defined types,
+// attributes and storage settings are copied along, and the data is streamed
+// in slabs bounded by CopyBufferSize.
+func (v Var) CopyTo(dstGroup *Group, opts ...CopyOption) (Var, error) {
+	if v.IsNull() {
+		return NewVarNull(), fmt.Errorf("error: attempt to invoke CopyTo on a Null variable")
+	}
+	if dstGroup.IsNull() {
+		return NewVarNull(), fmt.Errorf("error: attempt to invoke CopyTo with a Null destination group")
+	}
+	cfg := newCopyConfig(opts)
+	name := cfg.name
+	if name == "" {
+		var err error
+		if name, err = v.GetName(); err != nil {
+			return NewVarNull(), err
+		}
+	}
+	return copyVar(v, dstGroup, name, cfg)
+}
+
+// CopyTo recreates the user defined types, dimensions, attributes and
+// variables of g in dst, and with recursive set the child groups as well,
+// reusing child groups of dst that have the same name. See Var.CopyTo for
+// how variables are copied.
+func (g *Group) CopyTo(dst *Group, recursive bool, opts ...CopyOption) error {
+	if g.IsNull() {
+		return fmt.Errorf("error: attempt to invoke CopyTo on a Null group")
+	}
+	if dst.IsNull() {
+		return fmt.Errorf("error: attempt to invoke CopyTo with a Null destination group")
+	}
+	return copyGroup(g, dst, recursive, newCopyConfig(opts))
+}
+
+func copyGroup(src *Group, dst *Group, recursive bool, cfg *copyConfig) error {
+	_, typeIds, err := ncInqTypeids(src.id)
+	if err != nil {
+		return err
+	}
+	for _, xtype := range typeIds {
+		if _, err := copyType(src.id, xtype, dst.id); err != nil {
+			return err
+		}
+	}
+
+	// define the dimensions here so that those shared by several variables
+	// end up in the same group as in the source
+	_, dimIds, err := NcInqDimids(src.id, false)
+	if err != nil {
+		return err
+	}
+	for _, dimID := range dimIds {
+		if _, err := copyDim(NewDim(*src, dimID), dst, Current); err != nil {
+			return err
+		}
+	}
+
+	if err := copyAtts(src.id, NCGLOBAL, dst.id, NCGLOBAL); err != nil {
+		return err
+	}
+
+	vars, err := src.GetVarsM(Current)
+	if err != nil {
+		return err
+	}
+	for _, e := range vars {
+		if e.Value == cfg.skip {
+			continue
+		}
+		if _, err := copyVar(e.Value, dst, e.Name, cfg); err != nil {
+			return err
+		}
+	}
+
+	if !recursive {
+		return nil
+	}
+	children, err := src.GetGroupsM(ChildrenGrps)
+	if err != nil {
+		return err
+	}
+	for _, e := range children {
+		dstChild, err := dst.GetGroup(e.Name, ChildrenGrps)
+		if err != nil {
+			return err
+		}
+		if dstChild.IsNull() {
+			CheckDefineMode(dst.id)
+			if dstChild, err = dst.AddGroup(e.Name); err != nil {
+				return err
+			}
+		}
+		if err := copyGroup(e.Value, dstChild, true, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyVar(v Var, dst *Group, name string, cfg *copyConfig) (Var, error) {
+	xtype, err := NcInqVartype(v.groupId, v.myId)
+	if err != nil {
+		return NewVarNull(), err
+	}
+	dstType, err := copyType(v.groupId, xtype, dst.id)
+	if err != nil {
+		return NewVarNull(), err
+	}
+
+	dims, err := v.GetDims()
+	if err != nil {
+		return NewVarNull(), err
+	}
+	shape := make([]int, len(dims))
+	dimIds := make([]ID, len(dims))
+	for i, dim := range dims {
+		if shape[i], err = dim.GetSize(); err != nil {
+			return NewVarNull(), err
+		}
+		dstDim, err := copyDim(dim, dst, ParentsAndCurrent)
+		if err != nil {
+			return NewVarNull(), err
+		}
+		dimIds[i] = dstDim.ID()
+	}
+
+	CheckDefineMode(dst.id)
+	dstID, err := NcDefVar(dst.id, name, dstType, dimIds)
+	if err != nil {
+		return NewVarNull(), err
+	}
+	dstVar := NewVar(*dst, dstID)
+
+	var chunks []int
+	supported, err := cfg.storageSupported(v.groupId, dst.id)
+	if err != nil {
+		return NewVarNull(), err
+	}
+	if supported {
+		if _, chunks, err = ncInqVarChunking(v.groupId, v.myId, len(dims)); err != nil {
+			return NewVarNull(), err
+		}
+		if !cfg.noStorage {
+			if err := copyStorage(v, dstVar, chunks); err != nil {
+				return NewVarNull(), err
+			}
+		}
+	}
+
+	if err := copyAtts(v.groupId, v.myId, dst.id, dstID); err != nil {
+		return NewVarNull(), err
+	}
+	if cfg.noData {
+		return dstVar, nil
+	}
+	return dstVar, copyData(v, xtype, dstVar, shape, chunks, cfg.bufferSize)
+}
+
+// copyDim returns the dimension named like dim found in dst at location,
+// defining it in dst when there is none.
+func copyDim(dim Dim, dst *Group, location Location) (Dim, error) {
+	name, err := dim.Name()
+	if err != nil {
+		return NewDimNull(), err
+	}
+	size, err := dim.GetSize()
+	if err != nil {
+		return NewDimNull(), err
+	}
+	isUnlimited, err := dim.IsUnlimited()
+	if err != nil {
+		return NewDimNull(), err
+	}
+
+	dstDim, err := dst.GetDim(name, location)
+	if err != nil {
+		return NewDimNull(), err
+	}
+	if dstDim.IsNull() {
+		if isUnlimited {
+			return dst.AddDimUl(name)
+		}
+		return dst.AddDim(name, uint(size))
+	}
+
+	dstIsUnlimited, err := dstDim.IsUnlimited()
+	if err != nil {
+		return NewDimNull(), err
+	}
+	dstSize, err := dstDim.GetSize()
+	if err != nil {
+		return NewDimNull(), err
+	}
+	if isUnlimited != dstIsUnlimited || (!isUnlimited && size != dstSize) {
+		return NewDimNull(), fmt.Errorf("error: dimension %q already exists in the destination with a different size", name)
+	}
+	return dstDim, nil
+}
+
+// copyType returns the type of dst equal to xtype of src, defining it, and the
+// types it is built from, when dst has none. Atomic types are returned as is.
+func copyType(src ID, xtype NcType, dst ID) (NcType, error) {
+	if xtype < C.NC_FIRSTUSERTYPEID {
+		return xtype, nil
+	}
+	name, size, baseType, nFields, class, err := ncInqUserType(src, xtype)
+	if err != nil {
+		return 0, err
+	}
+	if dstType, err := ncInqTypeid(dst, name); err == nil {
+		equal, err := ncInqTypeEqual(src, xtype, dst, dstType)
+		if err != nil {
+			return 0, err
+		}
+		if !equal {
+			return 0, fmt.Errorf("error: type %q already exists in the destination with a different definition", name)
+		}
+		return dstType, nil
+	}
+
+	CheckDefineMode(dst)
+	switch class {
+	case C.NC_COMPOUND:
+		dstType, err := ncDefCompound(dst, size, name)
+		if err != nil {
+			return 0, err
+		}
+		for i := 0; i < nFields; i++ {
+			fieldName, offset, fieldType, dimSizes, err := ncInqCompoundField(src, xtype, i)
+			if err != nil {
+				return 0, err
+			}
+			dstFieldType, err := copyType(src, fieldType, dst)
+			if err != nil {
+				return 0, err
+			}
+			if err := ncInsertArrayCompound(dst, dstType, fieldName, offset, dstFieldType, dimSizes); err != nil {
+				return 0, err
+			}
+		}
+		return dstType, nil
+	case C.NC_VLEN:
+		dstBaseType, err := copyType(src, baseType, dst)
+		if err != nil {
+			return 0, err
+		}
+		return ncDefVlen(dst, name, dstBaseType)
+	case C.NC_OPAQUE:
+		return ncDefOpaque(dst, size, name)
+	case C.NC_ENUM:
+		dstType, err := ncDefEnum(dst, baseType, name)
+		if err != nil {
+			return 0, err
+		}
+		for i := 0; i < nFields; i++ {
+			memberName, value, err := ncInqEnumMember(src, xtype, i)
+			if err != nil {
+				return 0, err
+			}
+			if err := ncInsertEnum(dst, dstType, memberName, value); err != nil {
+				return 0, err
+			}
+		}
+		return dstType, nil
+	default:
+		return 0, fmt.Errorf("error: unknown class %d of type %q", class, name)
+	}
+}
+
+// copyAtts copies the attributes of varID in src to dstVarID in dst, first
+// copying the user defined types they use.
+func copyAtts(src ID, varID ID, dst ID, dstVarID ID) error {
+	atts, err := getAtts(src, varID)
+	if err != nil {
+		return err
+	}
+	CheckDefineMode(dst)
+	for _, a := range atts {
+		xtype, err := ncInqAtttype(src, varID, a.Name())
+		if err != nil {
+			return err
+		}
+		if _, err := copyType(src, xtype, dst); err != nil {
+			return err
+		}
+		if err := ncCopyAtt(src, varID, a.Name(), dst, dstVarID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyStorage copies chunking, compression filters, quantization, checksum,
+// endianness and fill mode of v to dst. chunks is nil for contiguous
+// variables. Filters are defined in the order of v; one that is not
+// available to the library for dst, such as a missing plugin, is an error
+// rather than being dropped.
+func copyStorage(v Var, dst Var, chunks []int) error {
+	if chunks != nil {
+		if err := ncDefVarChunking(dst.groupId, dst.myId, chunks); err != nil {
+			return err
+		}
+	}
+	shuffle, _, _, err := ncInqVarDeflate(v.groupId, v.myId)
+	if err != nil {
+		return err
+	}
+	if shuffle {
+		if err := ncDefVarDeflate(dst.groupId, dst.myId, true, false, 0); err != nil {
+			return err
+		}
+	}
+	filterIDs, err := ncInqVarFilterIds(v.groupId, v.myId)
+	if err != nil {
+		return err
+	}
+	for _, id := range filterIDs {
+		if err := copyFilter(v, dst, id); err != nil {
+			name, _ := v.GetName()
+			return fmt.Errorf("error: cannot copy filter %d of variable %q: %v", id, name, err)
+		}
+	}
+	mode, nsd, err := ncInqVarQuantize(v.groupId, v.myId)
+	if err != nil {
+		return err
+	}
+	if mode != C.NC_NOQUANTIZE {
+		if err := ncDefVarQuantize(dst.groupId, dst.myId, mode, nsd); err != nil {
+			return err
+		}
+	}
+	fletcher32, err := ncInqVarFletcher32(v.groupId, v.myId)
+	if err != nil {
+		return err
+	}
+	if fletcher32 {
+		if err := ncDefVarFletcher32(dst.groupId, dst.myId); err != nil {
+			return err
+		}
+	}
+	endian, err := ncInqVarEndian(v.groupId, v.myId)
+	if err != nil {
+		return err
+	}
+	if endian != C.NC_ENDIAN_NATIVE {
+		if err := ncDefVarEndian(dst.groupId, dst.myId, endian); err != nil {
+			return err
+		}
+	}
+	noFill, err := ncInqVarNoFill(v.groupId, v.myId)
+	if err != nil {
+		return err
+	}
+	if noFill {
+		return ncDefVarNoFill(dst.groupId, dst.myId)
+	}
+	return nil
+}
+
+// copyFilter defines the filter id of v on dst with the same parameters.
+// Shuffle and fletcher32 are copied on their own by copyStorage.
+func copyFilter(v Var, dst Var, id uint) error {
+	switch id {
+	case C.H5Z_FILTER_SHUFFLE, C.H5Z_FILTER_FLETCHER32:
+		return nil
+	case C.H5Z_FILTER_SZIP:
+		// the library adds internal bits to the stored parameters
+		mask, pixels, err := ncInqVarSzip(v.groupId, v.myId)
+		if err != nil {
+			return err
+		}
+		return ncDefVarSzip(dst.groupId, dst.myId, mask&(C.NC_SZIP_NN|C.NC_SZIP_EC), pixels)
+	}
+	params, err := ncInqVarFilterInfo(v.groupId, v.myId, id)
+	if err != nil {
+		return err
+	}
+	return ncDefVarFilter(dst.groupId, dst.myId, id, params)
+}
+
+// copyData streams the values of v to dst in slabs of at most bufferSize
+// bytes, in the memory layout of the variable type.
+func copyData(v Var, xtype NcType, dst Var, shape, chunks []int, bufferSize int) error {
+	typeSize, err := ncInqTypeSize(v.groupId, xtype)
+	if err != nil {
+		return err
+	}
+	// strings and vlens are read into memory allocated by the library
+	needsReclaim := xtype == C.NC_STRING || xtype >= C.NC_FIRSTUSERTYPEID
+
+	if err := CheckDataMode(dst.groupId); err != nil {
+		return err
+	}
+	var buf []byte
+	return forEachSlab(shape, chunks, bufferSize/typeSize, func(start, count []int) error {
+		n := 1
+		for _, c := range count {
+			n *= c
+		}
+		if cap(buf) < n*typeSize {
+			buf = make([]byte, n*typeSize)
+		}
+		buf = buf[:n*typeSize]
+		cStart, cCount := toSizeT(start), toSizeT(count)
+		if err := ncGetVaraRaw(v.groupId, v.myId, cStart, cCount, buf); err != nil {
+			return err
+		}
+		err := ncPutVaraRaw(dst.groupId, dst.myId, cStart, cCount, buf)
+		if needsReclaim {
+			if rerr := ncReclaimData(v.groupId, xtype, buf, n); err == nil {
+				err = rerr
+			}
+		}
+		return err
+	})
+}
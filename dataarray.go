@@ -0,0 +1,371 @@
+package netcdf4
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"time"
+)
+
+// DataArray holds the values of a variable labelled with the names of its
+// dimensions, the values of their coordinate variables and the variable's
+// attributes, so that data can be selected by coordinate value rather than
+// by index.
+type DataArray[T Numeric] struct {
+	Name   string
+	Dims   []string
+	Values *Array[T]
+	// Coords holds the values of the coordinate variable of each dimension
+	// that has one, keyed by dimension name. A coordinate variable is a 1-D
+	// variable with the same name as its dimension; those that are not
+	// numeric, such as station names, are left out.
+	Coords map[string][]float64
+	// CoordAttrs holds the attributes of the coordinate variables, such as
+	// the units and calendar of a time coordinate.
+	CoordAttrs map[string]map[string]interface{}
+	// CoordBounds holds the cell bounds of a coordinate, a pair per value,
+	// such as the intervals of Resample. WriteDataArray writes them as the
+	// variable named by the bounds or climatology attribute of the
+	// coordinate.
+	CoordBounds map[string][][2]float64
+	Attrs       map[string]interface{}
+}
+
+// ReadDataArray reads the whole variable together with its coordinate
+// variables and attributes. Attributes of user defined types are left out.
+func ReadDataArray[T Numeric](v Var) (*DataArray[T], error) {
+	values, err := ReadArray[T](v)
+	if err != nil {
+		return nil, err
+	}
+	return newDataArray(v, values)
+}
+
+// newDataArray labels values, data read from v, with the dimension names,
+// coordinate variables and attributes of v.
+func newDataArray[T Numeric](v Var, values *Array[T]) (*DataArray[T], error) {
+	name, err := v.GetName()
+	if err != nil {
+		return nil, err
+	}
+	dims, err := v.GetDims()
+	if err != nil {
+		return nil, err
+	}
+	da := &DataArray[T]{
+		Name:       name,
+		Dims:       make([]string, len(dims)),
+		Values:     values,
+		Coords:     map[string][]float64{},
+		CoordAttrs: map[string]map[string]interface{}{},
+	}
+	for i, dim := range dims {
+		if da.Dims[i], err = dim.Name(); err != nil {
+			return nil, err
+		}
+		cv, ok, err := coordinateVar(dim)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		t, err := cv.GetType()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := t.newSlice(0); err != nil {
+			continue
+		}
+		coord, err := ReadArray[float64](cv)
+		if err != nil {
+			return nil, err
+		}
+		da.Coords[da.Dims[i]] = coord.Data()
+		if da.CoordAttrs[da.Dims[i]], err = varAttrs(cv); err != nil {
+			return nil, err
+		}
+	}
+	if da.Attrs, err = varAttrs(v); err != nil {
+		return nil, err
+	}
+	return da, nil
+}
+
+// varAttrs reads the attributes of v, leaving out those of user defined
+// types.
+func varAttrs(v Var) (map[string]interface{}, error) {
+	atts, err := v.GetAtts()
+	if err != nil {
+		return nil, err
+	}
+	attrs := map[string]interface{}{}
+	for _, att := range atts {
+		t, err := att.GetType()
+		if err != nil {
+			return nil, err
+		}
+		if t.IsNull() {
+			continue
+		}
+		if attrs[att.Name()], err = att.Values(); err != nil {
+			return nil, err
+		}
+	}
+	return attrs, nil
+}
+
+// coordinateVar finds the coordinate variable of dim: a 1-D variable named
+// after the dimension and defined on it, in the dimension's group or one of
+// its parents.
+func coordinateVar(dim Dim) (Var, bool, error) {
+	name, err := dim.Name()
+	if err != nil {
+		return NewVarNull(), false, err
+	}
+	for grp := dim.GetParentGroup(); grp != nil; grp = grp.GetParentGroup() {
+		varID, err := ncInqVarid(grp.id, name)
+		if err != nil {
+			continue
+		}
+		_, dimIDs, err := NcInqVardimid(grp.id, varID)
+		if err != nil {
+			return NewVarNull(), false, err
+		}
+		if len(dimIDs) == 1 && dimIDs[0] == dim.id {
+			return NewVar(*grp, varID), true, nil
+		}
+		return NewVarNull(), false, nil
+	}
+	return NewVarNull(), false, nil
+}
+
+// DimIndex returns the position of the dimension name.
+func (d *DataArray[T]) DimIndex(name string) (int, error) {
+	for i, dim := range d.Dims {
+		if dim == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("error: %s has no dimension %q", d.Name, name)
+}
+
+// ISel selects index along dimension dim, which is dropped from the result.
+func (d *DataArray[T]) ISel(dim string, index int) (*DataArray[T], error) {
+	axis, err := d.DimIndex(dim)
+	if err != nil {
+		return nil, err
+	}
+	return d.selAxis(axis, index, index+1, true)
+}
+
+// ISelRange selects the indices start up to, but not including, stop along
+// dimension dim.
+func (d *DataArray[T]) ISelRange(dim string, start, stop int) (*DataArray[T], error) {
+	axis, err := d.DimIndex(dim)
+	if err != nil {
+		return nil, err
+	}
+	return d.selAxis(axis, start, stop, false)
+}
+
+// Sel selects the position along dimension dim whose coordinate equals
+// value; the dimension is dropped from the result. Coordinates match within
+// a millionth of their smallest spacing, or the rounding error of a float32
+// value, so that Sel("lat", 0.1) finds 0.1 stored as float32; use SelNearest
+// to select the closest coordinate however far it is.
+func (d *DataArray[T]) Sel(dim string, value float64) (*DataArray[T], error) {
+	coord, err := d.coord(dim)
+	if err != nil {
+		return nil, err
+	}
+	tol := math.Abs(value) * 0x1p-23 // float32 epsilon
+	spacing := math.Inf(1)
+	for i := 1; i < len(coord); i++ {
+		if s := math.Abs(coord[i] - coord[i-1]); s > 0 {
+			spacing = min(spacing, s)
+		}
+	}
+	if !math.IsInf(spacing, 1) {
+		tol = max(tol, spacing*1e-6)
+	}
+	best := -1
+	for i, c := range coord {
+		if math.Abs(c-value) <= tol && (best < 0 || math.Abs(c-value) < math.Abs(coord[best]-value)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("error: no %s coordinate equals %v", dim, value)
+	}
+	return d.ISel(dim, best)
+}
+
+// SelTime selects the position along the time dimension dim whose
+// coordinate, decoded with the units and calendar held in CoordAttrs, is t;
+// the dimension is dropped from the result. Times are compared to the
+// microsecond, as DecodeTimes rounds them.
+func (d *DataArray[T]) SelTime(dim string, t CFTime) (*DataArray[T], error) {
+	coord, err := d.coord(dim)
+	if err != nil {
+		return nil, err
+	}
+	units, c, err := d.timeUnits(dim)
+	if err != nil {
+		return nil, err
+	}
+	if t.Calendar.canonical() != c {
+		return nil, fmt.Errorf("error: time %v of the %s calendar in a %s time axis", t, t.Calendar, c)
+	}
+	times, err := DecodeTimes(coord, units, c)
+	if err != nil {
+		return nil, err
+	}
+	for i, ct := range times {
+		if dt, err := ct.Sub(t); err == nil && dt.Abs() <= time.Microsecond/2 {
+			return d.ISel(dim, i)
+		}
+	}
+	return nil, fmt.Errorf("error: no %s coordinate is %v", dim, t)
+}
+
+// SelTimeRange selects the positions along the time dimension dim whose
+// times lie between lo and hi inclusive, encoding them with the units and
+// calendar held in CoordAttrs; see SelRange.
+func (d *DataArray[T]) SelTimeRange(dim string, lo, hi CFTime) (*DataArray[T], error) {
+	units, c, err := d.timeUnits(dim)
+	if err != nil {
+		return nil, err
+	}
+	bounds, err := EncodeTimes([]CFTime{lo, hi}, units, c)
+	if err != nil {
+		return nil, err
+	}
+	return d.SelRange(dim, bounds[0], bounds[1])
+}
+
+// timeUnits returns the units and calendar of the coordinate of dim.
+func (d *DataArray[T]) timeUnits(dim string) (string, Calendar, error) {
+	return coordTimeUnits(dim, d.CoordAttrs[dim])
+}
+
+// coordTimeUnits returns the units and calendar held in attrs, the
+// attributes of the coordinate of dim.
+func coordTimeUnits(dim string, attrs map[string]interface{}) (string, Calendar, error) {
+	units, _ := attrs["units"].(string)
+	if units == "" {
+		return "", "", fmt.Errorf("error: the coordinate of dimension %q has no units", dim)
+	}
+	calendar, _ := attrs["calendar"].(string)
+	c, err := ParseCalendar(calendar)
+	return units, c, err
+}
+
+// SelNearest selects the position along dimension dim whose coordinate is
+// closest to value; the dimension is dropped from the result.
+func (d *DataArray[T]) SelNearest(dim string, value float64) (*DataArray[T], error) {
+	coord, err := d.coord(dim)
+	if err != nil {
+		return nil, err
+	}
+	best := -1
+	for i, c := range coord {
+		if !math.IsNaN(c) && (best < 0 || math.Abs(c-value) < math.Abs(coord[best]-value)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("error: no %s coordinates to select from", dim)
+	}
+	return d.ISel(dim, best)
+}
+
+// SelRange selects the positions along dimension dim whose coordinates lie
+// between lo and hi inclusive. The coordinate may be increasing or
+// decreasing, but the selected positions must be contiguous.
+func (d *DataArray[T]) SelRange(dim string, lo, hi float64) (*DataArray[T], error) {
+	coord, err := d.coord(dim)
+	if err != nil {
+		return nil, err
+	}
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	start, stop := -1, -1
+	for i, c := range coord {
+		if c < lo || c > hi {
+			continue
+		}
+		if start < 0 {
+			start = i
+		} else if stop != i {
+			return nil, fmt.Errorf("error: %s coordinates in [%v, %v] are not contiguous", dim, lo, hi)
+		}
+		stop = i + 1
+	}
+	if start < 0 {
+		start, stop = 0, 0
+	}
+	return d.ISelRange(dim, start, stop)
+}
+
+func (d *DataArray[T]) coord(dim string) ([]float64, error) {
+	coord, ok := d.Coords[dim]
+	if !ok {
+		return nil, fmt.Errorf("error: dimension %q of %s has no coordinate variable", dim, d.Name)
+	}
+	return coord, nil
+}
+
+// selAxis selects start up to stop along axis, dropping the axis if drop is
+// set. Coordinates and attributes are carried over.
+func (d *DataArray[T]) selAxis(axis, start, stop int, drop bool) (*DataArray[T], error) {
+	size := d.Values.shape[axis]
+	if start < 0 || stop > size || start > stop {
+		return nil, fmt.Errorf("error: indices [%d, %d) out of range for dimension %q of size %d", start, stop, d.Dims[axis], size)
+	}
+	var values *Array[T]
+	if start == stop {
+		// an empty selection, which a Range cannot express
+		shape := d.Values.Shape()
+		shape[axis] = 0
+		values = MakeArray[T](shape...)
+	} else {
+		ranges := make([]Range, axis+1)
+		ranges[axis] = Range{Start: start, Stop: stop}
+		var err error
+		if values, err = d.Values.Slice(ranges...); err != nil {
+			return nil, err
+		}
+	}
+
+	out := &DataArray[T]{
+		Name:        d.Name,
+		Dims:        append([]string{}, d.Dims...),
+		Values:      values,
+		Coords:      maps.Clone(d.Coords),
+		CoordAttrs:  maps.Clone(d.CoordAttrs),
+		CoordBounds: maps.Clone(d.CoordBounds),
+		Attrs:       maps.Clone(d.Attrs),
+	}
+	name := d.Dims[axis]
+	if coord, ok := d.Coords[name]; ok {
+		out.Coords[name] = append([]float64{}, coord[start:stop]...)
+	}
+	if bounds, ok := d.CoordBounds[name]; ok {
+		out.CoordBounds[name] = append([][2]float64{}, bounds[start:stop]...)
+	}
+	if drop {
+		shape := values.Shape()
+		shape = append(shape[:axis], shape[axis+1:]...)
+		var err error
+		if out.Values, err = values.Reshape(shape...); err != nil {
+			return nil, err
+		}
+		out.Dims = append(out.Dims[:axis], out.Dims[axis+1:]...)
+		delete(out.Coords, name)
+		delete(out.CoordAttrs, name)
+		delete(out.CoordBounds, name)
+	}
+	return out, nil
+}
@@ -1,5 +1,7 @@
 package netcdf4
 
+import "fmt"
+
 //Dim is a representative of a Dimension
 type Dim struct {
 	nullObject bool
@@ -37,26 +39,26 @@ func (dim Dim) GetParentGroup() *Group {
 	return NewGroup(dim.group)
 }
 
-// returns true if this dimension is unlimited.
-//func (dim Dim) IsUnlimited() bool {
-//
-//	ncInqUnlimdims(ncId ID) (nunlimdimsp, unlimdimidsp int, err error)
-//
-//int numlimdims;
-//int* unlimdimidsp=NULL;
-//// get the number of unlimited dimensions
-//ncxxCheck(nc_inq_unlimdims(group,&numlimdims,unlimdimidsp),__FILE__,__LINE__);
-//if (numlimdims){
-//// get all the unlimited dimension ids in this group
-//vector<int> unlimdimid(numlimdims);
-//ncxxCheck(nc_inq_unlimdims(group,&numlimdims,&unlimdimid[0]),__FILE__,__LINE__);
-//vector<int>::iterator it;
-//// now look to see if this dimension is unlimited
-//it = find(unlimdimid.begin(),unlimdimid.end(),id);
-//return it != unlimdimid.end();
-//}
-//return false;
-//}
+// IsUnlimited returns true if this dimension is unlimited. The dimension may
+// be defined in a parent of the group it was obtained from, so the parents
+// are searched as well.
+func (dim Dim) IsUnlimited() (bool, error) {
+	if dim.IsNull() {
+		return false, fmt.Errorf("error: attempt to invoke IsUnlimited on a Null dimension")
+	}
+	for grp := NewGroup(dim.group); grp != nil; grp = grp.GetParentGroup() {
+		_, unlimDimIds, err := NcInqUnlimdims(grp.id)
+		if err != nil {
+			return false, err
+		}
+		for _, id := range unlimDimIds {
+			if id == dim.id {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
 
 /*ID returns the he netCDF Id of this dimension. */
 func (dim Dim) ID() ID {
@@ -0,0 +1,681 @@
+package netcdf4
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FeatureType is a CF discrete sampling geometry type, the value of the
+// featureType global attribute.
+type FeatureType string
+
+// Known feature types
+const (
+	PointFeature             FeatureType = "point"
+	TimeSeriesFeature        FeatureType = "timeSeries"
+	TrajectoryFeature        FeatureType = "trajectory"
+	ProfileFeature           FeatureType = "profile"
+	TimeSeriesProfileFeature FeatureType = "timeSeriesProfile"
+	TrajectoryProfileFeature FeatureType = "trajectoryProfile"
+)
+
+// Feature is one feature of a discrete sampling geometry: a time series,
+// trajectory or profile, or for the timeSeriesProfile and trajectoryProfile
+// types a station or trajectory holding its profiles.
+type Feature struct {
+	// Index is the position of the feature along its instance dimension.
+	Index int
+	// ID is the value of the variable with the cf_role of the feature, ""
+	// if there is none.
+	ID string
+	// Instance holds the values of the instance variables, such as the
+	// station position or name: float64 for numbers and string for text.
+	Instance map[string]interface{}
+	// Obs holds the unpacked values of each numeric observation variable,
+	// NaN for missing values, in the order they are stored.
+	Obs map[string][]float64
+	// ObsText holds the values of the text observation variables.
+	ObsText map[string][]string
+	// Profiles holds the profiles of a timeSeriesProfile or
+	// trajectoryProfile feature; their observations are in Obs.
+	Profiles []Feature
+}
+
+// DSG layouts of the elements of an instance
+const (
+	dsgContiguous = iota // contiguous ragged array, with a sample_dimension count variable
+	dsgIndexed           // indexed ragged array, with an instance_dimension index variable
+	dsgMultidim          // 2-D (instance, element) variables, or 1-D shared ones
+	dsgSingle            // one feature, scalar instance variables
+)
+
+// dsgLevel maps the instances of a dimension to the elements of another:
+// features to observations, or stations to profiles.
+type dsgLevel struct {
+	layout        int
+	instance      Dim
+	element       Dim
+	count         int     // number of instances
+	starts, sizes []int   // dsgContiguous
+	rows          [][]int // dsgIndexed
+	elements      int     // dsgMultidim and dsgSingle: elements per instance
+	role          Var     // the cf_role variable, null if none
+	vars          []Var   // instance variables
+}
+
+// DSGReader reads the features of a CF discrete sampling geometry file.
+// It understands contiguous and indexed ragged arrays, the multidimensional
+// representations, and single features, and for timeSeriesProfile and
+// trajectoryProfile the ragged representation where a profile index
+// variable points at the stations and a count variable at the
+// observations.
+type DSGReader struct {
+	FeatureType FeatureType
+	top         *dsgLevel
+	profiles    *dsgLevel // profile to observations for the two-level types
+	obsVars     []*dsgObsVar
+	columns     map[[2]ID]*dsgColumn
+}
+
+// dsgObsVar is an observation variable with its shape and packing, looked
+// up once rather than for every slab read.
+type dsgObsVar struct {
+	v       Var
+	name    string
+	isText  bool
+	first   ID    // the first dimension
+	shape   []int // of the strings for text variables
+	packing Packing
+}
+
+// dsgBlockRows is the most rows read at once for the scattered elements of
+// an indexed ragged array.
+const dsgBlockRows = 4096
+
+// dsgColumn holds the whole data of a variable.
+type dsgColumn struct {
+	values []float64
+	text   []string
+}
+
+// NewDSGReader prepares to read the discrete sampling geometry in group
+// g, whose type is given by the featureType attribute of g or a parent.
+func NewDSGReader(g *Group) (*DSGReader, error) {
+	if g.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke NewDSGReader on a Null group")
+	}
+	r := &DSGReader{columns: map[[2]ID]*dsgColumn{}}
+	for grp := g; grp != nil && r.FeatureType == ""; grp = grp.GetParentGroup() {
+		if att, err := grp.GetAtt("featureType"); err == nil {
+			text, err := att.Text()
+			if err != nil {
+				return nil, err
+			}
+			r.FeatureType = FeatureType(strings.TrimSpace(text))
+		}
+	}
+	if r.FeatureType == "" {
+		return nil, fmt.Errorf("error: no featureType attribute")
+	}
+
+	ncVars, err := g.GetVarsM(Current)
+	if err != nil {
+		return nil, err
+	}
+	vars := ncVars.Values()
+
+	// the variables linking dimensions, and the cf_role ones
+	var counts, indexes []Var
+	roles := map[string]Var{}
+	for _, v := range vars {
+		if _, err := v.GetAtt("sample_dimension"); err == nil {
+			counts = append(counts, v)
+		}
+		if _, err := v.GetAtt("instance_dimension"); err == nil {
+			indexes = append(indexes, v)
+		}
+		if role, ok, err := textAttr(v, "cf_role"); err != nil {
+			return nil, err
+		} else if ok {
+			roles[strings.TrimSpace(role)] = v
+		}
+	}
+	if len(counts) > 1 || len(indexes) > 1 {
+		return nil, fmt.Errorf("error: more than one sample_dimension or instance_dimension variable")
+	}
+
+	switch r.FeatureType {
+	case TimeSeriesProfileFeature, TrajectoryProfileFeature:
+		if len(counts) == 0 || len(indexes) == 0 {
+			return nil, fmt.Errorf("error: only the ragged representation of %s is supported", r.FeatureType)
+		}
+		if r.profiles, err = newContiguousLevel(g, counts[0]); err != nil {
+			return nil, err
+		}
+		if r.top, err = newIndexedLevel(g, indexes[0]); err != nil {
+			return nil, err
+		}
+		if r.top.element.ID() != r.profiles.instance.ID() {
+			return nil, fmt.Errorf("error: the index variable is not along the profile dimension")
+		}
+		r.profiles.role = NewVarNull()
+		if v, ok := roles["profile_id"]; ok {
+			r.profiles.role = v
+		}
+	case TimeSeriesFeature, TrajectoryFeature, ProfileFeature, PointFeature:
+		switch {
+		case len(counts) > 0:
+			r.top, err = newContiguousLevel(g, counts[0])
+		case len(indexes) > 0:
+			r.top, err = newIndexedLevel(g, indexes[0])
+		default:
+			r.top, err = newMultidimLevel(vars, roles)
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("error: unsupported featureType %q", r.FeatureType)
+	}
+	r.top.role = NewVarNull()
+	for _, role := range []string{"timeseries_id", "trajectory_id", "profile_id"} {
+		if r.profiles != nil && role == "profile_id" {
+			break
+		}
+		if v, ok := roles[role]; ok {
+			r.top.role = v
+			break
+		}
+	}
+
+	// sort the other variables by their first dimension
+	for _, v := range vars {
+		if isDSGLink(v, counts, indexes) {
+			continue
+		}
+		dims, err := v.GetDims()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(dims) == 0:
+			if r.top.layout == dsgSingle {
+				r.top.vars = append(r.top.vars, v)
+			}
+		case r.top.layout == dsgMultidim && len(dims) >= 2 && dims[0].ID() == r.top.instance.ID() && dims[1].ID() == r.top.element.ID():
+			if err := r.addObsVar(v); err != nil {
+				return nil, err
+			}
+		case r.top.layout != dsgSingle && dims[0].ID() == r.top.instance.ID():
+			r.top.vars = append(r.top.vars, v)
+		case r.profiles != nil && dims[0].ID() == r.profiles.instance.ID():
+			r.profiles.vars = append(r.profiles.vars, v)
+		case dims[0].ID() == r.elementDim().ID():
+			if err := r.addObsVar(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r, nil
+}
+
+// textAttr reads the text attribute name of v; ok is false if it is missing.
+func textAttr(v Var, name string) (text string, ok bool, err error) {
+	att, err := v.GetAtt(name)
+	if err != nil {
+		return "", false, nil
+	}
+	text, err = att.Text()
+	return text, true, err
+}
+
+func isDSGLink(v Var, lists ...[]Var) bool {
+	for _, list := range lists {
+		for _, l := range list {
+			if l.groupId == v.groupId && l.myId == v.myId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// elementDim returns the dimension of the observations.
+func (r *DSGReader) elementDim() Dim {
+	if r.profiles != nil {
+		return r.profiles.element
+	}
+	return r.top.element
+}
+
+// newContiguousLevel reads a count variable, which has a sample_dimension
+// attribute naming the dimension it counts elements of.
+func newContiguousLevel(g *Group, countVar Var) (*dsgLevel, error) {
+	l := &dsgLevel{layout: dsgContiguous}
+	var err error
+	if l.instance, l.element, err = linkDims(g, countVar, "sample_dimension"); err != nil {
+		return nil, err
+	}
+	sizes, err := ReadArray[int64](countVar)
+	if err != nil {
+		return nil, err
+	}
+	l.count = sizes.Len()
+	l.starts, l.sizes = make([]int, l.count), make([]int, l.count)
+	total := 0
+	for i, n := range sizes.Data() {
+		if n < 0 {
+			return nil, fmt.Errorf("error: negative count at index %d", i)
+		}
+		l.starts[i], l.sizes[i] = total, int(n)
+		total += int(n)
+	}
+	if size, err := l.element.GetSize(); err != nil {
+		return nil, err
+	} else if total > size {
+		return nil, fmt.Errorf("error: the counts add up to %d, more than the %d elements", total, size)
+	}
+	return l, nil
+}
+
+// newIndexedLevel reads an index variable, along the element dimension,
+// which has an instance_dimension attribute naming the dimension its values
+// index.
+func newIndexedLevel(g *Group, indexVar Var) (*dsgLevel, error) {
+	l := &dsgLevel{layout: dsgIndexed}
+	var err error
+	if l.element, l.instance, err = linkDims(g, indexVar, "instance_dimension"); err != nil {
+		return nil, err
+	}
+	if l.count, err = l.instance.GetSize(); err != nil {
+		return nil, err
+	}
+	index, err := indexVar.ReadUnpacked()
+	if err != nil {
+		return nil, err
+	}
+	l.rows = make([][]int, l.count)
+	for elem, x := range index.Data() {
+		// missing or out of range indices mark unused elements
+		if i := int(x); x == x && x >= 0 && i < l.count {
+			l.rows[i] = append(l.rows[i], elem)
+		}
+	}
+	return l, nil
+}
+
+// linkDims returns the dimension of a count or index variable and the one
+// its attribute names.
+func linkDims(g *Group, v Var, attName string) (own, named Dim, err error) {
+	dims, err := v.GetDims()
+	if err != nil {
+		return own, named, err
+	}
+	if len(dims) != 1 {
+		return own, named, fmt.Errorf("error: a variable with a %s attribute must be 1-D", attName)
+	}
+	name, _, err := textAttr(v, attName)
+	if err != nil {
+		return own, named, err
+	}
+	named, err = g.GetDim(strings.TrimSpace(name), ParentsAndCurrent)
+	if err != nil {
+		return own, named, err
+	}
+	if named.IsNull() {
+		return own, named, fmt.Errorf("error: no dimension %q named by %s", name, attName)
+	}
+	return dims[0], named, nil
+}
+
+// newMultidimLevel works out the instance and element dimensions of the
+// multidimensional representation from the cf_role variable, or treats the
+// file as a single feature if that variable is a scalar.
+func newMultidimLevel(vars []Var, roles map[string]Var) (*dsgLevel, error) {
+	var role Var
+	found := false
+	for _, name := range []string{"timeseries_id", "trajectory_id", "profile_id"} {
+		if v, ok := roles[name]; ok {
+			role, found = v, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("error: no cf_role variable to find the instance dimension")
+	}
+	dims, err := role.GetDims()
+	if err != nil {
+		return nil, err
+	}
+	t, err := role.GetType()
+	if err != nil {
+		return nil, err
+	}
+	// a Char id has a string length dimension after the instance one
+	if len(dims) == 0 || (len(dims) == 1 && t.GetId() == Char.GetId()) {
+		return newSingleLevel(vars)
+	}
+
+	l := &dsgLevel{layout: dsgMultidim, instance: dims[0], element: NewDimNull()}
+	if l.count, err = l.instance.GetSize(); err != nil {
+		return nil, err
+	}
+	// the element dimension is the second one of a 2-D numeric variable
+	for _, v := range vars {
+		vDims, err := v.GetDims()
+		if err != nil {
+			return nil, err
+		}
+		vt, err := v.GetType()
+		if err != nil {
+			return nil, err
+		}
+		if len(vDims) == 2 && vDims[0].ID() == l.instance.ID() && vt.GetId() != Char.GetId() {
+			l.element = vDims[1]
+			break
+		}
+	}
+	if l.element.IsNull() {
+		return nil, fmt.Errorf("error: no (instance, element) variables")
+	}
+	if l.elements, err = l.element.GetSize(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// newSingleLevel handles a file holding one feature: the observations are
+// along the first dimension of the variables with a coordinates attribute.
+func newSingleLevel(vars []Var) (*dsgLevel, error) {
+	l := &dsgLevel{layout: dsgSingle, count: 1, instance: NewDimNull()}
+	for _, v := range vars {
+		dims, err := v.GetDims()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := v.GetAtt("coordinates"); err == nil && len(dims) > 0 {
+			l.element = dims[0]
+			l.elements, err = l.element.GetSize()
+			return l, err
+		}
+	}
+	return nil, fmt.Errorf("error: no data variable with a coordinates attribute")
+}
+
+// Len returns the number of features.
+func (r *DSGReader) Len() int {
+	return r.top.count
+}
+
+// Feature reads feature i with its observations.
+func (r *DSGReader) Feature(i int) (Feature, error) {
+	if i < 0 || i >= r.top.count {
+		return Feature{}, fmt.Errorf("error: feature %d out of range [0:%d]", i, r.top.count)
+	}
+	f, err := r.instance(r.top, i)
+	if err != nil {
+		return Feature{}, err
+	}
+	elems := r.top.elementsOf(i)
+	if r.profiles == nil {
+		err = r.observations(&f, r.top, i, elems)
+		return f, err
+	}
+	for _, p := range elems {
+		profile, err := r.instance(r.profiles, p)
+		if err != nil {
+			return Feature{}, err
+		}
+		if err := r.observations(&profile, r.profiles, p, r.profiles.elementsOf(p)); err != nil {
+			return Feature{}, err
+		}
+		f.Profiles = append(f.Profiles, profile)
+	}
+	return f, nil
+}
+
+// All returns an iterator over the features in order. It stops after
+// yielding the first error.
+func (r *DSGReader) All() iter.Seq2[Feature, error] {
+	return func(yield func(Feature, error) bool) {
+		for i := 0; i < r.top.count; i++ {
+			f, err := r.Feature(i)
+			if !yield(f, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// elementsOf returns the element indices of instance i.
+func (l *dsgLevel) elementsOf(i int) []int {
+	var elems []int
+	switch l.layout {
+	case dsgContiguous:
+		for e := 0; e < l.sizes[i]; e++ {
+			elems = append(elems, l.starts[i]+e)
+		}
+	case dsgIndexed:
+		elems = l.rows[i]
+	default:
+		for e := 0; e < l.elements; e++ {
+			elems = append(elems, e)
+		}
+	}
+	return elems
+}
+
+// instance reads the ID and instance variables of instance i of l.
+func (r *DSGReader) instance(l *dsgLevel, i int) (Feature, error) {
+	f := Feature{Index: i, Instance: map[string]interface{}{}, Obs: map[string][]float64{}, ObsText: map[string][]string{}}
+	index := i
+	if l.layout == dsgSingle {
+		index = 0
+	}
+	if !l.role.IsNull() {
+		col, err := r.column(l.role)
+		if err != nil {
+			return Feature{}, err
+		}
+		if col.text != nil {
+			f.ID = col.text[index]
+		} else {
+			f.ID = strconv.FormatFloat(col.values[index], 'f', -1, 64)
+		}
+	}
+	for _, v := range l.vars {
+		name, err := v.GetName()
+		if err != nil {
+			return Feature{}, err
+		}
+		col, err := r.column(v)
+		if err != nil {
+			return Feature{}, err
+		}
+		if col.text != nil {
+			f.Instance[name] = col.text[index]
+		} else if index < len(col.values) {
+			f.Instance[name] = col.values[index]
+		}
+	}
+	return f, nil
+}
+
+// addObsVar adds v to the observation variables.
+func (r *DSGReader) addObsVar(v Var) error {
+	o := &dsgObsVar{v: v}
+	var err error
+	if o.name, err = v.GetName(); err != nil {
+		return err
+	}
+	dims, err := v.GetDims()
+	if err != nil {
+		return err
+	}
+	o.first = dims[0].ID()
+	t, err := v.GetType()
+	if err != nil {
+		return err
+	}
+	o.isText = t.GetId() == Char.GetId() || t.GetId() == String.GetId()
+	if o.isText {
+		o.shape, err = v.stringShape()
+	} else {
+		if o.packing, err = v.Packing(); err != nil {
+			return err
+		}
+		o.shape, err = v.Shape()
+	}
+	if err != nil {
+		return err
+	}
+	r.obsVars = append(r.obsVars, o)
+	return nil
+}
+
+// observations reads the observation variables at elems, the elements of
+// instance i of l, into f. Only those elements are read, see
+// dsgObsVar.readElements.
+func (r *DSGReader) observations(f *Feature, l *dsgLevel, i int, elems []int) error {
+	for _, o := range r.obsVars {
+		var values []float64
+		var text []string
+		var err error
+		// a 2-D multidimensional variable holds a row per instance
+		if l.layout == dsgMultidim && o.first == l.instance.ID() {
+			values, text, err = o.readRows(i, 1)
+		} else {
+			values, text, err = o.readElements(elems)
+		}
+		if err != nil {
+			return err
+		}
+		if o.isText {
+			f.ObsText[o.name] = text
+		} else {
+			f.Obs[o.name] = values
+		}
+	}
+	return nil
+}
+
+// readElements reads the rows elems along the first dimension, in that
+// order. Consecutive rows are read as one slab. Scattered rows, as in an
+// indexed ragged array, are read in increasing order in slabs of at most
+// dsgBlockRows rows, each spanning the rows needed from its first, so no
+// row is read twice and the rows between are read only when they are close.
+func (o *dsgObsVar) readElements(elems []int) ([]float64, []string, error) {
+	if len(elems) == 0 {
+		return []float64{}, []string{}, nil
+	}
+	consecutive := true
+	for k := range elems {
+		if elems[k] != elems[0]+k {
+			consecutive = false
+			break
+		}
+	}
+	if consecutive {
+		return o.readRows(elems[0], len(elems))
+	}
+
+	row := 1
+	for _, n := range o.shape[1:] {
+		row *= n
+	}
+	var values []float64
+	var text []string
+	if o.isText {
+		text = make([]string, len(elems)*row)
+	} else {
+		values = make([]float64, len(elems)*row)
+	}
+	// the positions in elems, by increasing element
+	order := make([]int, len(elems))
+	for k := range order {
+		order[k] = k
+	}
+	sort.SliceStable(order, func(a, b int) bool { return elems[order[a]] < elems[order[b]] })
+	for k := 0; k < len(order); {
+		start := elems[order[k]]
+		end := k + 1
+		for end < len(order) && elems[order[end]] < start+dsgBlockRows {
+			end++
+		}
+		blockValues, blockText, err := o.readRows(start, elems[order[end-1]]-start+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, pos := range order[k:end] {
+			from := (elems[pos] - start) * row
+			if o.isText {
+				copy(text[pos*row:(pos+1)*row], blockText[from:from+row])
+			} else {
+				copy(values[pos*row:(pos+1)*row], blockValues[from:from+row])
+			}
+		}
+		k = end
+	}
+	return values, text, nil
+}
+
+// readRows reads n rows from start along the first dimension: text for
+// String and Char variables, unpacked numbers otherwise.
+func (o *dsgObsVar) readRows(start, n int) ([]float64, []string, error) {
+	st, count := make([]int, len(o.shape)), append([]int{}, o.shape...)
+	st[0], count[0] = start, n
+	if o.isText {
+		text, err := o.v.GetStringsSlab(st, count)
+		return nil, text, err
+	}
+	size := 1
+	for _, c := range count {
+		size *= c
+	}
+	// read in the stored type so that fill values compare exactly
+	data, err := o.packing.Type.newSlice(size)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := o.v.GetSlab(st, count, data); err != nil {
+		return nil, nil, err
+	}
+	values := make([]float64, size)
+	if err := storedValues(data, o.packing.Unsigned, values); err != nil {
+		return nil, nil, err
+	}
+	for i, x := range values {
+		values[i] = o.packing.Unpack(x)
+	}
+	return values, nil, nil
+}
+
+// column reads and caches the whole data of v, an instance variable: text
+// for String and Char variables, unpacked numbers otherwise.
+func (r *DSGReader) column(v Var) (*dsgColumn, error) {
+	key := [2]ID{v.groupId, v.myId}
+	if col, ok := r.columns[key]; ok {
+		return col, nil
+	}
+	t, err := v.GetType()
+	if err != nil {
+		return nil, err
+	}
+	col := &dsgColumn{}
+	if t.GetId() == Char.GetId() || t.GetId() == String.GetId() {
+		if col.text, err = v.GetStrings(); err != nil {
+			return nil, err
+		}
+	} else {
+		values, err := v.ReadUnpacked()
+		if err != nil {
+			return nil, err
+		}
+		col.values = values.Data()
+	}
+	r.columns[key] = col
+	return col, nil
+}
@@ -0,0 +1,358 @@
+package netcdf4
+
+import (
+	"fmt"
+	"math"
+)
+
+// Station is one instance of a TimeSeriesWriter: a station of a timeSeries
+// or a trajectory of a trajectory feature type.
+type Station struct {
+	ID string
+	// Lat and Lon are the station position in degrees; trajectories take
+	// their positions from each Observation instead.
+	Lat, Lon float64
+	// Alt is the station altitude in metres, written only if
+	// TimeSeriesConfig.Altitude is set; trajectories take it from each
+	// Observation instead.
+	Alt float64
+}
+
+// ObsVar describes a data variable of a TimeSeriesWriter.
+type ObsVar struct {
+	Name string
+	// Type is the netCDF type of the variable, Float if unset.
+	Type         Type
+	Units        string
+	StandardName string
+	LongName     string
+	// Packing, if not nil, is written with DefPacking and used to pack the
+	// values; its Type must be Type.
+	Packing *Packing
+}
+
+// TimeSeriesConfig describes the file a TimeSeriesWriter creates.
+type TimeSeriesConfig struct {
+	// FeatureType is TimeSeriesFeature, the default, or TrajectoryFeature.
+	FeatureType FeatureType
+	// TimeUnits are the units of the time variable, by default
+	// "seconds since 1970-01-01 00:00:00".
+	TimeUnits string
+	// Calendar is the calendar of the time variable, Standard by default.
+	Calendar Calendar
+	// Altitude adds an alt variable holding Station.Alt, or Observation.Alt
+	// for trajectories.
+	Altitude bool
+	Vars     []ObsVar
+}
+
+// Observation is one record written by a TimeSeriesWriter.
+type Observation struct {
+	// Station is the ID of the station or trajectory.
+	Station string
+	Time    CFTime
+	// Lat, Lon and Alt are the position of a trajectory observation; Alt,
+	// in metres, is written only if TimeSeriesConfig.Altitude is set.
+	Lat, Lon, Alt float64
+	// Values maps the names of the data variables to their values; a
+	// variable that is missing, or NaN, is written as its fill value.
+	Values map[string]float64
+}
+
+// TimeSeriesWriter writes a CF timeSeries or trajectory discrete sampling
+// geometry as an indexed ragged array: a station (or trajectory) dimension
+// holding the instance variables, and an unlimited obs dimension to which
+// each Observation appends a record, linked to its station by a
+// station_index (or trajectory_index) variable. Observations may thus come
+// in any order.
+type TimeSeriesWriter struct {
+	FeatureType FeatureType
+	stations    map[string]int
+	units       string
+	calendar    Calendar
+	time        Var
+	index       Var
+	lat, lon    Var // trajectory only
+	alt         Var // trajectory with altitude only
+	vars        []Var
+	varNames    []string
+	names       map[string]int
+	packings    []Packing
+	records     int
+}
+
+// NewTimeSeriesWriter defines the dimensions, variables and the featureType
+// and Conventions attributes of a timeSeries or trajectory in group g, which
+// must be empty, and writes the station variables. Observations are then
+// added with Write.
+func NewTimeSeriesWriter(g *Group, cfg TimeSeriesConfig, stations []Station) (*TimeSeriesWriter, error) {
+	if g.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke NewTimeSeriesWriter on a Null group")
+	}
+	w := &TimeSeriesWriter{
+		FeatureType: cfg.FeatureType,
+		stations:    map[string]int{},
+		alt:         NewVarNull(),
+		units:       cfg.TimeUnits,
+		calendar:    cfg.Calendar,
+		names:       map[string]int{},
+	}
+	if w.FeatureType == "" {
+		w.FeatureType = TimeSeriesFeature
+	}
+	if w.units == "" {
+		w.units = "seconds since 1970-01-01 00:00:00"
+	}
+	if w.calendar == "" {
+		w.calendar = Standard
+	}
+	if _, err := EncodeTimes(nil, w.units, w.calendar); err != nil {
+		return nil, err
+	}
+
+	instanceDim, role := "station", "timeseries_id"
+	switch w.FeatureType {
+	case TimeSeriesFeature:
+	case TrajectoryFeature:
+		instanceDim, role = "trajectory", "trajectory_id"
+	default:
+		return nil, fmt.Errorf("error: TimeSeriesWriter cannot write featureType %q", w.FeatureType)
+	}
+	if len(stations) == 0 {
+		return nil, fmt.Errorf("error: NewTimeSeriesWriter needs at least one %s", instanceDim)
+	}
+	idLen := 1
+	ids := make([]string, len(stations))
+	for i, s := range stations {
+		if s.ID == "" {
+			return nil, fmt.Errorf("error: %s %d has no ID", instanceDim, i)
+		}
+		if _, ok := w.stations[s.ID]; ok {
+			return nil, fmt.Errorf("error: duplicate %s ID %q", instanceDim, s.ID)
+		}
+		w.stations[s.ID] = i
+		ids[i] = s.ID
+		idLen = max(idLen, len(s.ID))
+	}
+
+	// dimensions
+	stationDim, err := g.AddDim(instanceDim, uint(len(stations)))
+	if err != nil {
+		return nil, err
+	}
+	strlenDim, err := g.AddDim(instanceDim+"_strlen", uint(idLen))
+	if err != nil {
+		return nil, err
+	}
+	obsDim, err := g.AddDimUl("obs")
+	if err != nil {
+		return nil, err
+	}
+
+	// instance variables
+	idVar, err := defDSGVar(g, instanceDim+"_id", Char, []Dim{stationDim, strlenDim}, [][2]string{
+		{"cf_role", role},
+		{"long_name", instanceDim + " identifier"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	coords := "time lat lon"
+	var lat, lon, alt Var
+	posDim := stationDim
+	if w.FeatureType == TrajectoryFeature {
+		posDim = obsDim
+	}
+	if lat, err = defDSGVar(g, "lat", Double, []Dim{posDim}, [][2]string{
+		{"standard_name", "latitude"},
+		{"long_name", "latitude"},
+		{"units", "degrees_north"},
+	}); err != nil {
+		return nil, err
+	}
+	if lon, err = defDSGVar(g, "lon", Double, []Dim{posDim}, [][2]string{
+		{"standard_name", "longitude"},
+		{"long_name", "longitude"},
+		{"units", "degrees_east"},
+	}); err != nil {
+		return nil, err
+	}
+	if cfg.Altitude {
+		if alt, err = defDSGVar(g, "alt", Double, []Dim{posDim}, [][2]string{
+			{"standard_name", "altitude"},
+			{"long_name", "altitude"},
+			{"units", "m"},
+			{"positive", "up"},
+			{"axis", "Z"},
+		}); err != nil {
+			return nil, err
+		}
+		coords += " alt"
+	}
+	coords += " " + instanceDim + "_id"
+
+	// observation variables
+	if w.time, err = defDSGVar(g, "time", Double, []Dim{obsDim}, [][2]string{
+		{"standard_name", "time"},
+		{"long_name", "time"},
+		{"units", w.units},
+		{"calendar", string(w.calendar)},
+	}); err != nil {
+		return nil, err
+	}
+	if w.index, err = defDSGVar(g, instanceDim+"_index", Int, []Dim{obsDim}, [][2]string{
+		{"long_name", "index of the " + instanceDim + " of this observation"},
+		{"instance_dimension", instanceDim},
+	}); err != nil {
+		return nil, err
+	}
+	for _, ov := range cfg.Vars {
+		if _, ok := w.names[ov.Name]; ok || ov.Name == "" {
+			return nil, fmt.Errorf("error: invalid or duplicate variable name %q", ov.Name)
+		}
+		t := ov.Type
+		if t.IsNull() || t.GetId() == 0 {
+			t = Float
+		}
+		atts := [][2]string{{"coordinates", coords}}
+		for _, att := range [][2]string{{"standard_name", ov.StandardName}, {"long_name", ov.LongName}, {"units", ov.Units}} {
+			if att[1] != "" {
+				atts = append(atts, att)
+			}
+		}
+		v, err := defDSGVar(g, ov.Name, t, []Dim{obsDim}, atts)
+		if err != nil {
+			return nil, err
+		}
+		if ov.Packing != nil {
+			if err := v.DefPacking(*ov.Packing); err != nil {
+				return nil, err
+			}
+		}
+		p, err := v.Packing()
+		if err != nil {
+			return nil, err
+		}
+		w.names[ov.Name] = len(w.vars)
+		w.vars = append(w.vars, v)
+		w.varNames = append(w.varNames, ov.Name)
+		w.packings = append(w.packings, p)
+	}
+
+	// global attributes
+	if _, err := g.PutAtt("featureType", string(w.FeatureType)); err != nil {
+		return nil, err
+	}
+	if _, err := g.GetAtt("Conventions"); err != nil {
+		if _, err := g.PutAtt("Conventions", "CF-1.8"); err != nil {
+			return nil, err
+		}
+	}
+
+	// station data
+	if err := idVar.PutStrings(ids); err != nil {
+		return nil, err
+	}
+	if w.FeatureType == TrajectoryFeature {
+		w.lat, w.lon = lat, lon
+		if cfg.Altitude {
+			w.alt = alt
+		}
+		return w, nil
+	}
+	lats, lons, alts := make([]float64, len(stations)), make([]float64, len(stations)), make([]float64, len(stations))
+	for i, s := range stations {
+		lats[i], lons[i], alts[i] = s.Lat, s.Lon, s.Alt
+	}
+	if err := lat.PutValAll(lats); err != nil {
+		return nil, err
+	}
+	if err := lon.PutValAll(lons); err != nil {
+		return nil, err
+	}
+	if cfg.Altitude {
+		if err := alt.PutValAll(alts); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// defDSGVar defines a variable with the given name and value text
+// attributes.
+func defDSGVar(g *Group, name string, t Type, dims []Dim, atts [][2]string) (Var, error) {
+	v, err := g.AddVar(name, t, dims)
+	if err != nil {
+		return NewVarNull(), err
+	}
+	for _, att := range atts {
+		if _, err := v.PutAtt(att[0], att[1]); err != nil {
+			return NewVarNull(), err
+		}
+	}
+	return v, nil
+}
+
+// Records returns the number of observations written.
+func (w *TimeSeriesWriter) Records() int {
+	return w.records
+}
+
+// Write appends obs and returns the new number of observations. The
+// observation is checked and packed before anything is written; if the
+// library then fails part way through, the variables written so far keep
+// the new record.
+func (w *TimeSeriesWriter) Write(obs Observation) (int, error) {
+	station, ok := w.stations[obs.Station]
+	if !ok {
+		return w.records, fmt.Errorf("error: unknown %s %q", w.FeatureType, obs.Station)
+	}
+	for name := range obs.Values {
+		if _, ok := w.names[name]; !ok {
+			return w.records, fmt.Errorf("error: no data variable %q", name)
+		}
+	}
+	t, err := EncodeTimes([]CFTime{obs.Time}, w.units, w.calendar)
+	if err != nil {
+		return w.records, err
+	}
+	values := make([]float64, len(w.vars))
+	for i := range w.vars {
+		x, ok := obs.Values[w.varNames[i]]
+		if !ok {
+			x = math.NaN()
+		}
+		if x, err = w.packings[i].Pack(x); err != nil {
+			return w.records, err
+		}
+		values[i] = w.packings[i].signedForm(x)
+	}
+
+	start, count := []int{w.records}, []int{1}
+	if err := w.time.PutSlab(start, count, t); err != nil {
+		return w.records, err
+	}
+	if w.FeatureType == TrajectoryFeature {
+		if err := w.lat.PutSlab(start, count, []float64{obs.Lat}); err != nil {
+			return w.records, err
+		}
+		if err := w.lon.PutSlab(start, count, []float64{obs.Lon}); err != nil {
+			return w.records, err
+		}
+		if !w.alt.IsNull() {
+			if err := w.alt.PutSlab(start, count, []float64{obs.Alt}); err != nil {
+				return w.records, err
+			}
+		}
+	}
+	for i, v := range w.vars {
+		if err := v.PutSlab(start, count, values[i:i+1]); err != nil {
+			return w.records, err
+		}
+	}
+	if err := w.index.PutSlab(start, count, []int32{int32(station)}); err != nil {
+		return w.records, err
+	}
+	w.records++
+	return w.records, nil
+}
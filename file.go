@@ -2,6 +2,10 @@ package netcdf4
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 )
 
 //File represnets an opened netCDF file
@@ -10,6 +14,26 @@ type File struct {
 	pathInUse string
 	mode      FileMode
 	format    FileFormat
+
+	// KD-trees over latitude and longitude built by ExtractPoints
+	pointTrees map[pointTreeKey]*pointTree
+}
+
+// openFiles maps the root group ID of each file opened with File.Open to its
+// File, so that a Group can find the file it belongs to. openFilesMu guards
+// it and the pointTrees of the files in it.
+var (
+	openFilesMu sync.Mutex
+	openFiles   = map[ID]*File{}
+)
+
+// openFile returns the File opened with File.Open that holds group id.
+func openFile(id ID) (*File, bool) {
+	root := rootGroupID(id)
+	openFilesMu.Lock()
+	defer openFilesMu.Unlock()
+	f, ok := openFiles[root]
+	return f, ok
 }
 
 //NewFile creates a new file with an empty group set
@@ -43,6 +67,9 @@ func (f *File) Open(filePath string, fMode FileMode, fFormat FileFormat) (err er
 	f.pathInUse = filePath
 	gNcid = f.id
 	f.nullObject = false
+	openFilesMu.Lock()
+	openFiles[f.id] = f
+	openFilesMu.Unlock()
 	return
 }
 
@@ -50,6 +77,10 @@ func (f *File) Open(filePath string, fMode FileMode, fFormat FileFormat) (err er
 func (f *File) Close() error {
 	if !f.nullObject {
 		gNcid = -1
+		openFilesMu.Lock()
+		delete(openFiles, f.id)
+		f.pointTrees = nil
+		openFilesMu.Unlock()
 		err := ncClose(f.id)
 		if err != nil {
 			return err
@@ -77,3 +108,139 @@ func (f File) Enddef() error {
 func (f File) GetPathInUse() string {
 	return f.pathInUse
 }
+
+// RemoveVar deletes the variable at path (see Lookup). netCDF cannot delete
+// variables, so the file is rewritten without it into a temporary file next
+// to the original, which then replaces it and is reopened for writing.
+// Only f itself is updated: every Group, Var, Dim or Att obtained from f
+// before the call refers to the old file afterwards. The copy is made as
+// by Group.CopyTo.
+func (f *File) RemoveVar(path string) error {
+	if f.nullObject {
+		return fmt.Errorf("error: attempt to invoke RemoveVar on a closed file")
+	}
+	if f.mode == READ {
+		return fmt.Errorf("error: RemoveVar needs a file opened for writing")
+	}
+	obj, err := f.Lookup(path)
+	if err != nil {
+		return err
+	}
+	v, ok := obj.(Var)
+	if !ok {
+		return fmt.Errorf("error: %q is not a variable", path)
+	}
+	format, err := ncInqFormat(f.id)
+	if err != nil {
+		return err
+	}
+	if format == UNKNOWN {
+		return fmt.Errorf("error: RemoveVar does not support the format of %q", f.pathInUse)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.pathInUse), filepath.Base(f.pathInUse)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	tmpID, err := Create(tmpPath, REPLACE, format)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	cfg := newCopyConfig(nil)
+	cfg.skip = v
+	if err := copyGroup(f.Group, NewGroup(tmpID), true, cfg); err != nil {
+		ncClose(tmpID)
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := ncClose(tmpID); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// swap the copy in and reopen it
+	filePath, fileFormat := f.pathInUse, f.format
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		if openErr := f.Open(filePath, WRITE, fileFormat); openErr != nil {
+			return fmt.Errorf("%v; reopening %q: %v", err, filePath, openErr)
+		}
+		return err
+	}
+	return f.Open(filePath, WRITE, fileFormat)
+}
+
+// RemoveVar deletes the variable name of the group, see File.RemoveVar. The
+// file is rewritten and reopened: g then refers to the same group of the new
+// file, but other objects of the file must be looked up again. If the file
+// was opened with File.Open, that File is updated; otherwise its ID is
+// closed and the new one is only reachable through g.
+func (g *Group) RemoveVar(name string) error {
+	if g.IsNull() {
+		return fmt.Errorf("error: attempt to invoke RemoveVar on a Null group")
+	}
+	path, err := g.Path()
+	if err != nil {
+		return err
+	}
+	f, ok := openFile(g.id)
+	if !ok {
+		if f, err = adoptFile(rootGroupID(g.id)); err != nil {
+			return err
+		}
+		defer func() {
+			openFilesMu.Lock()
+			delete(openFiles, f.id)
+			openFilesMu.Unlock()
+		}()
+	}
+	if err := f.RemoveVar(strings.TrimSuffix(path, "/") + "/" + name); err != nil {
+		return err
+	}
+	obj, err := f.Lookup(path)
+	if err != nil {
+		return err
+	}
+	g.id = obj.(*Group).id
+	return nil
+}
+
+// adoptFile returns a File for the file of root group id, which was not
+// opened with File.Open.
+func adoptFile(id ID) (*File, error) {
+	filePath, err := ncInqPath(id)
+	if err != nil {
+		return nil, err
+	}
+	format, err := ncInqFormat(id)
+	if err != nil {
+		return nil, err
+	}
+	writable, err := ncInqWritable(id)
+	if err != nil {
+		return nil, err
+	}
+	mode := READ
+	if writable {
+		mode = WRITE
+	}
+	return &File{Group: NewGroup(id), pathInUse: filePath, mode: mode, format: format}, nil
+}
+
+// rootGroupID returns the ID of the root group of the file holding group id.
+func rootGroupID(id ID) ID {
+	for {
+		parent, err := ncInqGrpParent(id)
+		if err != nil {
+			return id
+		}
+		id = parent
+	}
+}
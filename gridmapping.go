@@ -0,0 +1,400 @@
+package netcdf4
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GridMapping describes the map projection of a variable's horizontal
+// coordinates, read from the grid mapping variable its 'grid_mapping'
+// attribute names.
+type GridMapping struct {
+	// Name is the grid_mapping_name, e.g. "lambert_conformal_conic".
+	Name string
+	// VarName is the name of the grid mapping variable.
+	VarName string
+	// Params holds the numeric attributes of the grid mapping variable, such
+	// as "standard_parallel" or "false_easting".
+	Params map[string][]float64
+	// Text holds its text attributes, such as "crs_wkt" or
+	// "sweep_angle_axis".
+	Text map[string]string
+}
+
+// GridMapping reads the grid mapping variable named by the variable's
+// 'grid_mapping' attribute. Of the extended form "crs: x y crs2: lat lon"
+// the first mapping is used. ok is false if v has no 'grid_mapping'.
+func (v Var) GridMapping() (m GridMapping, ok bool, err error) {
+	if v.IsNull() {
+		return m, false, fmt.Errorf("error: attempt to invoke GridMapping on a Null variable")
+	}
+	att, err := v.GetAtt("grid_mapping")
+	if err != nil {
+		return m, false, nil
+	}
+	text, err := att.Text()
+	if err != nil {
+		return m, false, err
+	}
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return m, false, fmt.Errorf("error: empty grid_mapping attribute")
+	}
+	m.VarName = strings.TrimSuffix(fields[0], ":")
+	gv, found := findVar(v.GetParentGroup(), m.VarName)
+	if !found {
+		return m, false, fmt.Errorf("error: grid mapping variable %q not found", m.VarName)
+	}
+
+	atts, err := gv.GetAtts()
+	if err != nil {
+		return m, false, err
+	}
+	m.Params, m.Text = map[string][]float64{}, map[string]string{}
+	for _, a := range atts {
+		t, err := a.GetType()
+		if err != nil {
+			return m, false, err
+		}
+		switch {
+		case t.IsNull():
+		case t.GetId() == Char.GetId() || t.GetId() == String.GetId():
+			if m.Text[a.Name()], err = a.Text(); err != nil {
+				return m, false, err
+			}
+		default:
+			if m.Params[a.Name()], err = a.Float64s(); err != nil {
+				return m, false, err
+			}
+		}
+	}
+	m.Name = strings.TrimSpace(m.Text["grid_mapping_name"])
+	if m.Name == "" {
+		return m, false, fmt.Errorf("error: grid mapping variable %q has no grid_mapping_name", m.VarName)
+	}
+	return m, true, nil
+}
+
+// Param returns the first value of the numeric parameter name.
+func (m GridMapping) Param(name string) (float64, bool) {
+	if values := m.Params[name]; len(values) > 0 {
+		return values[0], true
+	}
+	return 0, false
+}
+
+func (m GridMapping) param(name string, def float64) float64 {
+	if x, ok := m.Param(name); ok {
+		return x
+	}
+	return def
+}
+
+// standardParallels returns the one or two standard parallels.
+func (m GridMapping) standardParallels() (sp1, sp2 float64, n int) {
+	sp := m.Params["standard_parallel"]
+	switch len(sp) {
+	case 0:
+		return 0, 0, 0
+	case 1:
+		return sp[0], sp[0], 1
+	default:
+		return sp[0], sp[1], 2
+	}
+}
+
+// ellipsoid returns the semi-major axis and inverse flattening of the
+// figure of the earth, 0 for a sphere. Without any figure parameters it is
+// WGS 84.
+func (m GridMapping) ellipsoid() (a, rf float64) {
+	if r, ok := m.Param("earth_radius"); ok {
+		return r, 0
+	}
+	a, ok := m.Param("semi_major_axis")
+	if !ok {
+		return 6378137, 298.257223563
+	}
+	if rf, ok := m.Param("inverse_flattening"); ok {
+		return a, rf
+	}
+	if b, ok := m.Param("semi_minor_axis"); ok && b != a {
+		return a, a / (a - b)
+	}
+	return a, 0
+}
+
+// mappingParam is a projection parameter of a PROJ string and a WKT2
+// conversion.
+type mappingParam struct {
+	proj  string // PROJ parameter, without '+' and '='
+	wkt   string // WKT2 parameter name
+	epsg  int    // EPSG parameter code, 0 for none
+	angle bool   // degrees rather than metres (or a scale factor)
+	scale bool   // a unitless scale factor
+	value float64
+}
+
+// projection returns the PROJ name, the WKT2 method name and EPSG code, and
+// the parameters of the mapping.
+func (m GridMapping) projection() (proj, method string, code int, params []mappingParam, err error) {
+	angle := func(proj, wkt string, epsg int, value float64) mappingParam {
+		return mappingParam{proj: proj, wkt: wkt, epsg: epsg, angle: true, value: value}
+	}
+	length := func(proj, wkt string, epsg int, value float64) mappingParam {
+		return mappingParam{proj: proj, wkt: wkt, epsg: epsg, value: value}
+	}
+	scale := func(proj, wkt string, epsg int, value float64) mappingParam {
+		return mappingParam{proj: proj, wkt: wkt, epsg: epsg, scale: true, value: value}
+	}
+	fe := length("x_0", "False easting", 8806, m.param("false_easting", 0))
+	fn := length("y_0", "False northing", 8807, m.param("false_northing", 0))
+	lat0 := m.param("latitude_of_projection_origin", 0)
+	lon0 := m.param("longitude_of_projection_origin", 0)
+	sp1, sp2, nsp := m.standardParallels()
+
+	switch m.Name {
+	case "albers_conical_equal_area", "lambert_conformal_conic":
+		if nsp == 0 {
+			return "", "", 0, nil, fmt.Errorf("error: %s needs a standard_parallel", m.Name)
+		}
+	}
+
+	switch m.Name {
+	case "albers_conical_equal_area":
+		return "aea", "Albers Equal Area", 9822, []mappingParam{
+			angle("lat_0", "Latitude of false origin", 8821, lat0),
+			angle("lon_0", "Longitude of false origin", 8822, m.param("longitude_of_central_meridian", 0)),
+			angle("lat_1", "Latitude of 1st standard parallel", 8823, sp1),
+			angle("lat_2", "Latitude of 2nd standard parallel", 8824, sp2),
+			length("x_0", "Easting at false origin", 8826, fe.value),
+			length("y_0", "Northing at false origin", 8827, fn.value),
+		}, nil
+	case "azimuthal_equidistant":
+		return "aeqd", "Azimuthal Equidistant", 1125, []mappingParam{
+			angle("lat_0", "Latitude of natural origin", 8801, lat0),
+			angle("lon_0", "Longitude of natural origin", 8802, lon0),
+			fe, fn,
+		}, nil
+	case "geostationary":
+		sweep := strings.ToLower(strings.TrimSpace(m.Text["sweep_angle_axis"]))
+		if sweep == "" {
+			sweep = "y"
+			if fixed := strings.ToLower(strings.TrimSpace(m.Text["fixed_angle_axis"])); fixed == "y" {
+				sweep = "x"
+			}
+		}
+		if sweep != "x" && sweep != "y" {
+			return "", "", 0, nil, fmt.Errorf("error: bad sweep_angle_axis %q", sweep)
+		}
+		return "geos +sweep=" + sweep, "Geostationary Satellite (Sweep " + strings.ToUpper(sweep) + ")", 0, []mappingParam{
+			angle("lon_0", "Longitude of natural origin", 8802, lon0),
+			length("h", "Satellite Height", 0, m.param("perspective_point_height", 0)),
+			fe, fn,
+		}, nil
+	case "lambert_azimuthal_equal_area":
+		return "laea", "Lambert Azimuthal Equal Area", 9820, []mappingParam{
+			angle("lat_0", "Latitude of natural origin", 8801, lat0),
+			angle("lon_0", "Longitude of natural origin", 8802, lon0),
+			fe, fn,
+		}, nil
+	case "lambert_conformal_conic":
+		lonC := m.param("longitude_of_central_meridian", 0)
+		if _, hasLat0 := m.Param("latitude_of_projection_origin"); sp1 == sp2 && (!hasLat0 || lat0 == sp1) {
+			// tangent at the origin
+			return "lcc +lat_1=" + formatNumber(sp1), "Lambert Conic Conformal (1SP)", 9801, []mappingParam{
+				angle("lat_0", "Latitude of natural origin", 8801, sp1),
+				angle("lon_0", "Longitude of natural origin", 8802, lonC),
+				scale("k_0", "Scale factor at natural origin", 8805, 1),
+				fe, fn,
+			}, nil
+		}
+		return "lcc", "Lambert Conic Conformal (2SP)", 9802, []mappingParam{
+			angle("lat_0", "Latitude of false origin", 8821, lat0),
+			angle("lon_0", "Longitude of false origin", 8822, lonC),
+			angle("lat_1", "Latitude of 1st standard parallel", 8823, sp1),
+			angle("lat_2", "Latitude of 2nd standard parallel", 8824, sp2),
+			length("x_0", "Easting at false origin", 8826, fe.value),
+			length("y_0", "Northing at false origin", 8827, fn.value),
+		}, nil
+	case "lambert_cylindrical_equal_area":
+		return "cea", "Lambert Cylindrical Equal Area", 9835, []mappingParam{
+			angle("lat_ts", "Latitude of 1st standard parallel", 8823, sp1),
+			angle("lon_0", "Longitude of natural origin", 8802, m.param("longitude_of_central_meridian", 0)),
+			fe, fn,
+		}, nil
+	case "mercator":
+		lonC := m.param("longitude_of_projection_origin", 0)
+		if nsp > 0 {
+			return "merc", "Mercator (variant B)", 9805, []mappingParam{
+				angle("lat_ts", "Latitude of 1st standard parallel", 8823, sp1),
+				angle("lon_0", "Longitude of natural origin", 8802, lonC),
+				fe, fn,
+			}, nil
+		}
+		return "merc", "Mercator (variant A)", 9804, []mappingParam{
+			angle("lat_0", "Latitude of natural origin", 8801, 0),
+			angle("lon_0", "Longitude of natural origin", 8802, lonC),
+			scale("k_0", "Scale factor at natural origin", 8805, m.param("scale_factor_at_projection_origin", 1)),
+			fe, fn,
+		}, nil
+	case "orthographic":
+		return "ortho", "Orthographic", 9840, []mappingParam{
+			angle("lat_0", "Latitude of natural origin", 8801, lat0),
+			angle("lon_0", "Longitude of natural origin", 8802, lon0),
+			fe, fn,
+		}, nil
+	case "polar_stereographic":
+		lonV := m.param("straight_vertical_longitude_from_pole", m.param("longitude_of_projection_origin", 0))
+		pole := 90.0
+		if lat0 < 0 {
+			pole = -90
+		}
+		if nsp > 0 {
+			return "stere +lat_0=" + formatNumber(pole), "Polar Stereographic (variant B)", 9829, []mappingParam{
+				angle("lat_ts", "Latitude of standard parallel", 8832, sp1),
+				angle("lon_0", "Longitude of origin", 8833, lonV),
+				fe, fn,
+			}, nil
+		}
+		return "stere", "Polar Stereographic (variant A)", 9810, []mappingParam{
+			angle("lat_0", "Latitude of natural origin", 8801, pole),
+			angle("lon_0", "Longitude of natural origin", 8802, lonV),
+			scale("k_0", "Scale factor at natural origin", 8805, m.param("scale_factor_at_projection_origin", 1)),
+			fe, fn,
+		}, nil
+	case "sinusoidal":
+		return "sinu", "Sinusoidal", 0, []mappingParam{
+			angle("lon_0", "Longitude of natural origin", 8802, lon0),
+			fe, fn,
+		}, nil
+	case "stereographic":
+		return "stere", "Oblique Stereographic", 9809, []mappingParam{
+			angle("lat_0", "Latitude of natural origin", 8801, lat0),
+			angle("lon_0", "Longitude of natural origin", 8802, lon0),
+			scale("k_0", "Scale factor at natural origin", 8805, m.param("scale_factor_at_projection_origin", 1)),
+			fe, fn,
+		}, nil
+	case "transverse_mercator":
+		return "tmerc", "Transverse Mercator", 9807, []mappingParam{
+			angle("lat_0", "Latitude of natural origin", 8801, lat0),
+			angle("lon_0", "Longitude of natural origin", 8802, m.param("longitude_of_central_meridian", 0)),
+			scale("k_0", "Scale factor at natural origin", 8805, m.param("scale_factor_at_central_meridian", 1)),
+			fe, fn,
+		}, nil
+	case "vertical_perspective":
+		return "nsper", "Vertical Perspective", 9838, []mappingParam{
+			angle("lat_0", "Latitude of topocentric origin", 8834, lat0),
+			angle("lon_0", "Longitude of topocentric origin", 8835, lon0),
+			length("h", "Viewpoint height", 8840, m.param("perspective_point_height", 0)),
+			fe, fn,
+		}, nil
+	case "rotated_latitude_longitude":
+		poleLat, ok1 := m.Param("grid_north_pole_latitude")
+		poleLon, ok2 := m.Param("grid_north_pole_longitude")
+		if !ok1 || !ok2 {
+			return "", "", 0, nil, fmt.Errorf("error: rotated_latitude_longitude needs grid_north_pole_latitude and grid_north_pole_longitude")
+		}
+		return "ob_tran +o_proj=longlat", "PROJ ob_tran o_proj=longlat", 0, []mappingParam{
+			angle("o_lon_p", "o_lon_p", 0, m.param("north_pole_grid_longitude", 0)),
+			angle("o_lat_p", "o_lat_p", 0, poleLat),
+			angle("lon_0", "lon_0", 0, normalizeLongitude(poleLon+180)),
+		}, nil
+	case "latitude_longitude":
+		return "longlat", "", 0, nil, nil
+	default:
+		return "", "", 0, nil, fmt.Errorf("error: unsupported grid mapping %q", m.Name)
+	}
+}
+
+// normalizeLongitude returns lon in [-180, 180).
+func normalizeLongitude(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+func formatNumber(x float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
+
+// PROJ returns the mapping as a PROJ string such as "+proj=lcc +lat_0=25
+// ...". Without figure of the earth parameters the WGS 84 ellipsoid is
+// assumed.
+func (m GridMapping) PROJ() (string, error) {
+	proj, _, _, params, err := m.projection()
+	if err != nil {
+		return "", err
+	}
+	parts := []string{"+proj=" + proj}
+	for _, p := range params {
+		parts = append(parts, "+"+p.proj+"="+formatNumber(p.value))
+	}
+	a, rf := m.ellipsoid()
+	switch {
+	case rf == 0:
+		parts = append(parts, "+R="+formatNumber(a))
+	case a == 6378137 && rf == 298.257223563:
+		parts = append(parts, "+ellps=WGS84")
+	default:
+		parts = append(parts, "+a="+formatNumber(a), "+rf="+formatNumber(rf))
+	}
+	if m.Name != "latitude_longitude" && m.Name != "rotated_latitude_longitude" {
+		parts = append(parts, "+units=m")
+	}
+	parts = append(parts, "+no_defs", "+type=crs")
+	return strings.Join(parts, " "), nil
+}
+
+// WKT returns the mapping as OGC WKT2 (2019). The 'crs_wkt' attribute is
+// returned as is if present. Without figure of the earth parameters the
+// WGS 84 ellipsoid is assumed.
+func (m GridMapping) WKT() (string, error) {
+	if wkt := strings.TrimSpace(m.Text["crs_wkt"]); wkt != "" {
+		return wkt, nil
+	}
+	_, method, code, params, err := m.projection()
+	if err != nil {
+		return "", err
+	}
+
+	const degree = `ANGLEUNIT["degree",0.0174532925199433]`
+	const metre = `LENGTHUNIT["metre",1]`
+	id := func(code int) string {
+		if code == 0 {
+			return ""
+		}
+		return `,ID["EPSG",` + strconv.Itoa(code) + `]`
+	}
+	a, rf := m.ellipsoid()
+	datum := `DATUM["unknown",ELLIPSOID["unknown",` + formatNumber(a) + `,` + formatNumber(rf) + `,` + metre + `]],` +
+		`PRIMEM["Greenwich",0,` + degree + `]`
+	geogCS := `CS[ellipsoidal,2],AXIS["latitude",north,ORDER[1],` + degree + `],AXIS["longitude",east,ORDER[2],` + degree + `]`
+
+	var conv strings.Builder
+	conv.WriteString(`METHOD["` + method + `"` + id(code) + `]`)
+	for _, p := range params {
+		unit := metre
+		switch {
+		case p.angle:
+			unit = degree
+		case p.scale:
+			unit = `SCALEUNIT["unity",1]`
+		}
+		conv.WriteString(`,PARAMETER["` + p.wkt + `",` + formatNumber(p.value) + `,` + unit + id(p.epsg) + `]`)
+	}
+
+	switch m.Name {
+	case "latitude_longitude":
+		return `GEOGCRS["unknown",` + datum + `,` + geogCS + `]`, nil
+	case "rotated_latitude_longitude":
+		return `GEOGCRS["unknown",BASEGEOGCRS["unknown",` + datum + `],` +
+			`DERIVINGCONVERSION["unknown",` + conv.String() + `],` + geogCS + `]`, nil
+	}
+	return `PROJCRS["unknown",BASEGEOGCRS["unknown",` + datum + `],` +
+		`CONVERSION["unknown",` + conv.String() + `],` +
+		`CS[Cartesian,2],AXIS["(E)",east,ORDER[1],` + metre + `],AXIS["(N)",north,ORDER[2],` + metre + `]]`, nil
+}
@@ -0,0 +1,170 @@
+package netcdf4
+
+import (
+	"strings"
+	"testing"
+)
+
+var gridMappingTests = []struct {
+	m    GridMapping
+	proj string
+	// wkt holds parts the WKT must contain
+	wkt []string
+}{
+	{
+		GridMapping{Name: "lambert_conformal_conic", Params: map[string][]float64{
+			"standard_parallel":             {33, 45},
+			"longitude_of_central_meridian": {-97},
+			"latitude_of_projection_origin": {40},
+		}},
+		"+proj=lcc +lat_0=40 +lon_0=-97 +lat_1=33 +lat_2=45 +x_0=0 +y_0=0 +ellps=WGS84 +units=m +no_defs +type=crs",
+		[]string{
+			`PROJCRS["unknown",`,
+			`ELLIPSOID["unknown",6378137,298.257223563,LENGTHUNIT["metre",1]]`,
+			`METHOD["Lambert Conic Conformal (2SP)",ID["EPSG",9802]]`,
+			`PARAMETER["Latitude of false origin",40,ANGLEUNIT["degree",0.0174532925199433],ID["EPSG",8821]]`,
+			`PARAMETER["Latitude of 2nd standard parallel",45,`,
+			`PARAMETER["Easting at false origin",0,LENGTHUNIT["metre",1],ID["EPSG",8826]]`,
+			`CS[Cartesian,2]`,
+		},
+	},
+	{
+		GridMapping{Name: "lambert_conformal_conic", Params: map[string][]float64{
+			"standard_parallel":             {25},
+			"longitude_of_central_meridian": {265},
+			"earth_radius":                  {6371229},
+		}},
+		"+proj=lcc +lat_1=25 +lat_0=25 +lon_0=265 +k_0=1 +x_0=0 +y_0=0 +R=6371229 +units=m +no_defs +type=crs",
+		[]string{
+			`ELLIPSOID["unknown",6371229,0,`,
+			`METHOD["Lambert Conic Conformal (1SP)",ID["EPSG",9801]]`,
+			`PARAMETER["Scale factor at natural origin",1,SCALEUNIT["unity",1],ID["EPSG",8805]]`,
+		},
+	},
+	{
+		GridMapping{Name: "polar_stereographic", Params: map[string][]float64{
+			"straight_vertical_longitude_from_pole": {-45},
+			"latitude_of_projection_origin":         {90},
+			"standard_parallel":                     {70},
+			"semi_major_axis":                       {6378273},
+			"semi_minor_axis":                       {6356889.449},
+		}},
+		"+proj=stere +lat_0=90 +lat_ts=70 +lon_0=-45 +x_0=0 +y_0=0 +a=6378273 +rf=298.279411123064 +units=m +no_defs +type=crs",
+		[]string{
+			`METHOD["Polar Stereographic (variant B)",ID["EPSG",9829]]`,
+			`PARAMETER["Latitude of standard parallel",70,`,
+			`PARAMETER["Longitude of origin",-45,`,
+		},
+	},
+	{
+		GridMapping{Name: "polar_stereographic", Params: map[string][]float64{
+			"straight_vertical_longitude_from_pole": {0},
+			"latitude_of_projection_origin":         {-90},
+			"scale_factor_at_projection_origin":     {0.994},
+		}},
+		"+proj=stere +lat_0=-90 +lon_0=0 +k_0=0.994 +x_0=0 +y_0=0 +ellps=WGS84 +units=m +no_defs +type=crs",
+		[]string{`METHOD["Polar Stereographic (variant A)",ID["EPSG",9810]]`},
+	},
+	{
+		GridMapping{Name: "transverse_mercator", Params: map[string][]float64{
+			"scale_factor_at_central_meridian": {0.9996},
+			"longitude_of_central_meridian":    {-3},
+			"false_easting":                    {500000},
+		}},
+		"+proj=tmerc +lat_0=0 +lon_0=-3 +k_0=0.9996 +x_0=500000 +y_0=0 +ellps=WGS84 +units=m +no_defs +type=crs",
+		[]string{
+			`METHOD["Transverse Mercator",ID["EPSG",9807]]`,
+			`PARAMETER["False easting",500000,LENGTHUNIT["metre",1],ID["EPSG",8806]]`,
+		},
+	},
+	{
+		GridMapping{Name: "mercator", Params: map[string][]float64{"standard_parallel": {20}}},
+		"+proj=merc +lat_ts=20 +lon_0=0 +x_0=0 +y_0=0 +ellps=WGS84 +units=m +no_defs +type=crs",
+		[]string{`METHOD["Mercator (variant B)",ID["EPSG",9805]]`},
+	},
+	{
+		GridMapping{Name: "geostationary", Params: map[string][]float64{
+			"longitude_of_projection_origin": {-75},
+			"perspective_point_height":       {35786023},
+		}, Text: map[string]string{"sweep_angle_axis": "x"}},
+		"+proj=geos +sweep=x +lon_0=-75 +h=35786023 +x_0=0 +y_0=0 +ellps=WGS84 +units=m +no_defs +type=crs",
+		[]string{
+			`METHOD["Geostationary Satellite (Sweep X)"]`,
+			`PARAMETER["Satellite Height",35786023,LENGTHUNIT["metre",1]]`,
+		},
+	},
+	{
+		GridMapping{Name: "rotated_latitude_longitude", Params: map[string][]float64{
+			"grid_north_pole_latitude":  {39.25},
+			"grid_north_pole_longitude": {-162},
+		}},
+		"+proj=ob_tran +o_proj=longlat +o_lon_p=0 +o_lat_p=39.25 +lon_0=18 +ellps=WGS84 +no_defs +type=crs",
+		[]string{
+			`GEOGCRS["unknown",BASEGEOGCRS[`,
+			`DERIVINGCONVERSION["unknown",METHOD["PROJ ob_tran o_proj=longlat"]`,
+			`PARAMETER["lon_0",18,`,
+			`CS[ellipsoidal,2]`,
+		},
+	},
+	{
+		GridMapping{Name: "latitude_longitude"},
+		"+proj=longlat +ellps=WGS84 +no_defs +type=crs",
+		[]string{`GEOGCRS["unknown",DATUM["unknown",ELLIPSOID["unknown",6378137,298.257223563,LENGTHUNIT["metre",1]]],` +
+			`PRIMEM["Greenwich",0,ANGLEUNIT["degree",0.0174532925199433]],CS[ellipsoidal,2],` +
+			`AXIS["latitude",north,ORDER[1],ANGLEUNIT["degree",0.0174532925199433]],` +
+			`AXIS["longitude",east,ORDER[2],ANGLEUNIT["degree",0.0174532925199433]]]`},
+	},
+}
+
+func TestGridMappingPROJ(t *testing.T) {
+	for _, tt := range gridMappingTests {
+		got, err := tt.m.PROJ()
+		if err != nil {
+			t.Errorf("%s: %v", tt.m.Name, err)
+			continue
+		}
+		if got != tt.proj {
+			t.Errorf("%s: PROJ() =\n%s, want\n%s", tt.m.Name, got, tt.proj)
+		}
+	}
+}
+
+func TestGridMappingWKT(t *testing.T) {
+	for _, tt := range gridMappingTests {
+		got, err := tt.m.WKT()
+		if err != nil {
+			t.Errorf("%s: %v", tt.m.Name, err)
+			continue
+		}
+		if strings.Count(got, "[") != strings.Count(got, "]") {
+			t.Errorf("%s: unbalanced WKT %s", tt.m.Name, got)
+		}
+		for _, part := range tt.wkt {
+			if !strings.Contains(got, part) {
+				t.Errorf("%s: WKT() = %s, missing %s", tt.m.Name, got, part)
+			}
+		}
+	}
+
+	m := GridMapping{Name: "transverse_mercator", Text: map[string]string{"crs_wkt": ` PROJCRS["ETRS89 / UTM zone 32N"] `}}
+	if got, err := m.WKT(); err != nil || got != `PROJCRS["ETRS89 / UTM zone 32N"]` {
+		t.Errorf("WKT() with crs_wkt = %q, %v", got, err)
+	}
+}
+
+func TestGridMappingErrors(t *testing.T) {
+	for _, m := range []GridMapping{
+		{Name: "lambert_conformal_conic"},
+		{Name: "albers_conical_equal_area"},
+		{Name: "rotated_latitude_longitude", Params: map[string][]float64{"grid_north_pole_latitude": {39.25}}},
+		{Name: "geostationary", Text: map[string]string{"sweep_angle_axis": "z"}},
+		{Name: "unknown_projection"},
+	} {
+		if got, err := m.PROJ(); err == nil {
+			t.Errorf("%s: PROJ() = %s, want an error", m.Name, got)
+		}
+		if got, err := m.WKT(); err == nil {
+			t.Errorf("%s: WKT() = %s, want an error", m.Name, got)
+		}
+	}
+}
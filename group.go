@@ -2,8 +2,6 @@ package netcdf4
 
 import (
 	"fmt"
-	"log"
-	"sort"
 	"strconv"
 	"strings"
 )
@@ -72,6 +70,9 @@ const (
 	Parents                       // Select from contents of parents groups.
 	Children                      // Select from contents of children groups.
 	All      Location = 0x07      // Select from contents of current, parents and child groups.
+
+	ParentsAndCurrent  = Current | Parents  // Select from contents of current and parents groups.
+	ChildrenAndCurrent = Current | Children // Select from contents of current and child groups.
 )
 
 func (l Location) String() string {
@@ -93,6 +94,12 @@ func (l Location) IsSet(o Location) bool {
 	return l&o == o
 }
 
+// Object is anything that can be found in the hierarchy of a file: a *Group,
+// a Var, a Dim or an Att. Use a type switch to get at the concrete value.
+type Object interface {
+	IsNull() bool
+}
+
 //NewGroupNull returns an empty group
 func NewGroupNull() *Group {
 	return &Group{nullObject: true, id: -1, useProposedStandardName: false}
@@ -125,6 +132,73 @@ func (g *Group) Name(fullName bool) (string, error) {
 	return ncInqGrpname(g.id)
 }
 
+// Path returns the full path of the group with "/" separating sub-groups,
+// e.g. "/USA/Wyoming". The root group is "/". It is the inverse of Lookup.
+func (g *Group) Path() (string, error) {
+	if g.IsNull() {
+		return "", fmt.Errorf("error: attempt to invoke Path on a Null group")
+	}
+	return ncInqGrpnameFull(g.id)
+}
+
+// Lookup returns the group, variable or dimension found at path.
+// An absolute path such as "/USA/Wyoming/average_temperature" is resolved
+// from the root group, a relative one such as "Wyoming/stations" from g.
+// Groups take precedence over variables and variables over dimensions.
+// Dimensions are scoped the way netCDF scopes them, so a dimension defined
+// in a parent group is found from its children as well.
+func (g *Group) Lookup(path string) (Object, error) {
+	if g.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke Lookup on a Null group")
+	}
+	if path == "" {
+		return nil, fmt.Errorf("error: empty path in Lookup")
+	}
+	// the library resolves every path from the id it is given, so absolute
+	// paths start from the root group
+	base := g.id
+	if strings.HasPrefix(path, "/") {
+		base = rootGroupID(g.id)
+	}
+	fullName := strings.TrimRight(path, "/")
+	if fullName == "" {
+		return NewGroup(base), nil
+	}
+	if grpID, err := ncInqGrpFullNcid(base, fullName); err == nil {
+		return NewGroup(grpID), nil
+	}
+
+	// split into the containing group and the name within it
+	parentID, name := base, fullName
+	if i := strings.LastIndex(fullName, "/"); i >= 0 {
+		if dir := fullName[:i]; dir != "" {
+			grpID, err := ncInqGrpFullNcid(base, dir)
+			if err != nil {
+				return nil, fmt.Errorf("error: no group %q in Lookup of %q: %v", dir, path, err)
+			}
+			parentID = grpID
+		}
+		name = fullName[i+1:]
+	}
+	parent := NewGroup(parentID)
+	if varID, err := ncInqVarid(parentID, name); err == nil {
+		return NewVar(*parent, varID), nil
+	}
+	if dimID, err := ncInqDimid(parentID, name); err == nil {
+		return NewDim(*parent, dimID), nil
+	}
+	return nil, fmt.Errorf("error: no group, variable or dimension at %q", path)
+}
+
+// RenameTo attempts to rename the group to name. The root group cannot be renamed.
+func (g *Group) RenameTo(name string) error {
+	if g.IsNull() {
+		return fmt.Errorf("error: attempt to invoke RenameTo on a Null group")
+	}
+	CheckDefineMode(g.id)
+	return NcRenameGrp(g.id, name)
+}
+
 // IsRootGroup returns true if this is the group root.
 func (g *Group) IsRootGroup() (bool, error) {
 	grpName, err := g.Name(false)
@@ -200,9 +274,11 @@ func (g *Group) GetGroupCount(location GroupLocation) (int, error) {
 	return n, nil
 }
 
-// GetGroupsM retrieves the ..... ?? Get the set of child Group objects.
-func (g *Group) GetGroupsM(location GroupLocation) (MultimapG, error) {
-	ncGroups := NewMultimapG()
+// GetGroupsM gets the collection of Group objects. Groups are ordered as
+// found: the current group, its children, its parents (nearest first) and
+// then the children of the children.
+func (g *Group) GetGroupsM(location GroupLocation) (Groups, error) {
+	var ncGroups Groups
 	if g.IsNull() {
 		return ncGroups, fmt.Errorf("error: attempt to invoke GetGroupsM on a Null group")
 	}
@@ -244,7 +320,7 @@ func (g *Group) GetGroupsM(location GroupLocation) (MultimapG, error) {
 			for {
 				parentGroup := tmpGroup.GetParentGroup()
 				if parentGroup == nil {
-					return ncGroups, err
+					break
 				}
 				if parentGroup.IsNull() {
 					break
@@ -265,18 +341,14 @@ func (g *Group) GetGroupsM(location GroupLocation) (MultimapG, error) {
 		if err != nil {
 			return ncGroups, err
 		}
-		for groupKey := range groupMs {
-			gps := groupMs.EqualRange(groupKey)
-			for _, gp := range gps {
-				childGroups, err := gp.GetGroupsM(AllChildrenGrps)
-
-				if err != nil {
-					return ncGroups, err
-				}
-				keys, fields := childGroups.GetAllPair()
-				for i := 0; i < len(keys); i++ {
-					ncGroups.Add(keys[i], fields[i])
-				}
+		for _, gp := range groupMs.Values() {
+			childGroups, err := gp.GetGroupsM(AllChildrenGrps)
+			if err != nil {
+				return ncGroups, err
+			}
+			keys, fields := childGroups.GetAllPair()
+			for i := 0; i < len(keys); i++ {
+				ncGroups.Add(keys[i], fields[i])
 			}
 		}
 	}
@@ -292,15 +364,14 @@ func (g *Group) GetGroup(name string, location GroupLocation) (*Group, error) {
 	if err != nil {
 		return NewGroupNull(), err
 	}
-	ret := ncGroups.EqualRange(name)
-	if len(ret) == 0 {
+	gp, ok := ncGroups.Get(name)
+	if !ok {
 		return NewGroupNull(), nil
 	}
-	gp := ret[0]
 	return gp, nil
 }
 
-//GetGroups returns all Group objects with a given name.  The returned map is nil if g is empty
+//GetGroups returns all Group objects with a given name.  The returned slice is empty if g is empty
 func (g *Group) GetGroups(name string, location GroupLocation) []*Group {
 	groups := []*Group{}
 	if g.IsNull() {
@@ -312,11 +383,7 @@ func (g *Group) GetGroups(name string, location GroupLocation) []*Group {
 		return groups
 	}
 
-	ret := grps.EqualRange(name)
-	for _, gp := range ret {
-		groups = append(groups, gp)
-	}
-	return groups
+	return append(groups, grps.EqualRange(name)...)
 }
 
 //AddGroup adds a child group to g
@@ -331,227 +398,140 @@ func (g *Group) AddGroup(name string) (*Group, error) {
 	return NewGroup(newID), nil
 }
 
-/*IsNull returns true if g is nul or this is a null object (no contents)*/
-func (g *Group) IsNull() bool {
-	return g == nil || g.nullObject
+// GetAtts gets the group (global) attributes in the order they are stored.
+func (g *Group) GetAtts() ([]Att, error) {
+	if g.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke GetAtts on a Null group")
+	}
+	return getAtts(g.id, NCGLOBAL)
 }
 
-//GetVarCount gets the number of Var objects in this group.
-func (g *Group) GetVarCount(location Location) int {
-	varsInGroup := func(groupID ID) int {
-		nvars, err := ncInqNvars(groupID)
-		log.Printf("Group.GetVarCount.func1() ncInqNvars(%d) failed: %v", groupID, err)
-		if err != nil {
-			return -1
-		}
-		return nvars
+// GetAtt gets the group (global) attribute name.
+func (g *Group) GetAtt(name string) (Att, error) {
+	if g.IsNull() {
+		return NewAttNull(), fmt.Errorf("error: attempt to invoke GetAtt on a Null group")
 	}
-	vars := 0
+	return getAtt(g.id, NCGLOBAL, name)
+}
 
-	// search in current group.
-	if location.IsSet(Current) && !g.nullObject {
-		v := varsInGroup(g.id)
-		if v == -1 {
-			return -1
-		}
-		vars += v
+// PutAtt creates or replaces the group (global) attribute name. value is a
+// string, a []string, or a number or slice of numbers stored with the
+// matching netCDF type.
+func (g *Group) PutAtt(name string, value interface{}) (Att, error) {
+	if g.IsNull() {
+		return NewAttNull(), fmt.Errorf("error: attempt to invoke PutAtt on a Null group")
 	}
+	CheckDefineMode(g.id)
+	return putAtt(g.id, NCGLOBAL, name, value)
+}
 
-	if location.IsSet(Parents) && !g.nullObject {
-		parent := g.GetParentGroup()
-		if parent != nil && parent.nullObject {
-			if v == -1 {
-				return -1
-			}
-			vars += v
-		}
+/*IsNull returns true if g is nul or this is a null object (no contents)*/
+func (g *Group) IsNull() bool {
+	return g == nil || g.nullObject
+}
 
-		tmpGroup, err := g.GetParentGroup()
-		if err != nil {
-			return -1, err
-		}
-		for !tmpGroup.IsNull() {
-			id, err := tmpGroup.ID()
-			if err != nil {
-				return -1, err
-			}
-			nvarsp, err := ncInqNvars(id)
-			if err != nil {
-				return -1, err
-			}
-			nvars += nvarsp
-			// continue loop with the parent.
-			tmpGroup, err = tmpGroup.GetParentGroup()
-			if err != nil {
-				return -1, err
-			}
-		}
-		v := varsInGroup(g.id)
-		if v == -1 {
-			return -1
-		}
-		vars += v
+// GetVarCount gets the number of Var objects.
+func (g *Group) GetVarCount(location Location) (int, error) {
+	if g.IsNull() {
+		return -1, fmt.Errorf("error: attempt to invoke GetVarCount on a Null group")
 	}
 
-	//
-	// tmpGroup := NewGroupFrom(g)
+	// intialize counter
+	nvars := 0
 
 	// search in current group
-	nvars := 0
-	if (location == ParentsAndCurrent || location == ChildrenAndCurrent || location == Current || location == All) && !tmpGroup.IsNull() {
-		id, err := tmpGroup.ID()
+	if location.IsSet(Current) {
+		nvarsp, err := ncInqNvars(g.id)
 		if err != nil {
 			return -1, err
 		}
-		nvars, err = ncInqNvars(id)
-		if err != nil {
-			return -1, err
-		}
-	}
-
-	// search recursively in all parent groups.
-	if location == Parents || location == ParentsAndCurrent || location == All {
-
+		nvars += nvarsp
 	}
-
-	// search recursively in all child groups
-	if location == ChildrenAndCurrent || location == Children || location == All {
+	// search in parent groups.
+	if location.IsSet(Parents) {
 		groups, err := g.GetGroupsM(ParentsGrps)
 		if err != nil {
 			return -1, err
 		}
-
-		_, gps := groups.GetAllPair()
-
-		for _, gp := range gps {
-			nvarTmp, err := gp.GetVarCount(ChildrenAndCurrent)
+		for _, gp := range groups.Values() {
+			nvarTmp, err := gp.GetVarCount(Current)
 			if err != nil {
 				return -1, err
 			}
 			nvars += nvarTmp
 		}
 	}
-	return nvars, nil
-}
-
-// GetVars returns a map of groups->variables stored in group.
-//GerVarsM Get the collection of Var objects.  It returns nil on errors
-func (g *Group) GetVars(location Location) map[string]sort.StringSlice {
-	rtn := map[string]sort.StringSlice{}
-	if g.nullObject {
-		return rtn
-	}
-
-	// search in current group.
-	tmpGroup := NewGroupFrom(g)
-
-	varsForGroup := func(id ID) sort.StringSlice {
-		r := sort.StringSlice{}
-		_, ids, err := NcInqVarids(g.id)
+	// search in child groups.
+	if location.IsSet(Children) {
+		groups, err := g.GetGroupsM(AllChildrenGrps)
 		if err != nil {
-			log.Printf("GetVars() NcInqVarids(%d) failed: %v", g.id, err)
-			return r
+			return -1, err
 		}
-		for _, id := range ids {
-			// tmpVar := NewVar(g, varIds[i])
-			name, err := NcInqVarname(g.id, id)
+		for _, gp := range groups.Values() {
+			nvarTmp, err := gp.GetVarCount(Current)
 			if err != nil {
-				log.Printf("GetVars() NcInqVarname(%d,%d) failed: %v", g.id, id, err)
+				return -1, err
 			}
-			r = append(r, name)
+			nvars += nvarTmp
 		}
-		return r
 	}
+	return nvars, nil
+}
 
-	// Current            Location = iota // Select from contents of current group.
-	// Parents                            // Select from contents of parents groups.
-	// Children                           // Select from contents of children groups.
-	// ParentsAndCurrent                  // Select from contents of current and parents groups.
-	// ChildrenAndCurrent                 // Select from contents of current and child groups.
-	// All                                // Select from contents of current, parents and child groups.
-
-	switch location {
-	case Parents, Children: //dont search up or down
-	default: //Current,  ParentsAndCurrent, ChildrenAndCurrent, All
-		// ChildrenAndCurrent                 // Select from contents of current and child groups.
-		// All                                // Select from contents of current, parents and child groups.
+// GetVarsM gets the collection of Var objects. Variables of the current
+// group come first, then those of the parents (nearest first) and then those
+// of the children, each in the order they were defined.
+func (g *Group) GetVarsM(location Location) (Vars, error) {
+	var ncVars Vars
+	if g.IsNull() {
+		return ncVars, fmt.Errorf("error: attempt to invoke GetVarsM on a Null group")
+	}
 
-		// get the number of variables.
-		// count, ids, err := NcInqVarids(g.id)
-		_, ids, err := NcInqVarids(g.id)
+	addVars := func(gp *Group) error {
+		_, varIds, err := NcInqVarids(gp.id)
 		if err != nil {
-			log.Printf("GetVars() NcInqVarids(%d) failed: %v", g.id, err)
-			return rtn
+			return err
 		}
-		rtn[""]
-		for _, id := range ids {
-			// tmpVar := NewVar(g, varIds[i])
-			name, err := NcInqVarname(g.id, id)
-			if err != nil {
-				log.Printf("GetVars() NcInqVarname(%d,%d) failed: %v", g.id, id, err)
-			}
-
+		for _, varID := range varIds {
+			tmpVar := NewVar(*gp, varID)
+			varName, err := tmpVar.GetName()
 			if err != nil {
-				return ncVars, err
+				return err
 			}
 			ncVars.Add(varName, tmpVar)
 		}
+		return nil
 	}
 
-	// if (location == ParentsAndCurrent || location == ChildrenAndCurrent || location == Current || location == All) && !tmpGroup.IsNull() {
-
-	// }
+	// search in current group.
+	if location.IsSet(Current) {
+		if err := addVars(g); err != nil {
+			return ncVars, err
+		}
+	}
 
 	// search recursively in all parent groups.
-	if location == Parents || location == ParentsAndCurrent || location == All {
-		tmpGroup, err = g.GetParentGroup()
+	if location.IsSet(Parents) {
+		groups, err := g.GetGroupsM(ParentsGrps)
 		if err != nil {
 			return ncVars, err
 		}
-		for !tmpGroup.IsNull() {
-			// get the number of variables
-			// get the number of variables.
-			tmpID, _ := tmpGroup.ID()
-			varCount, varIds, err := NcInqVarids(tmpID)
-			if err != nil {
-				return ncVars, err
-			}
-			for i := 0; i < varCount; i++ {
-				tmpVar := NewVar(g, varIds[i])
-				varName, err := tmpVar.GetName()
-				if err != nil {
-					return ncVars, err
-				}
-				ncVars.Add(varName, tmpVar)
-			}
-
-			// continue loop with the parent.
-			tmpGroup, err = tmpGroup.GetParentGroup()
-			if err != nil {
+		for _, gp := range groups.Values() {
+			if err := addVars(gp); err != nil {
 				return ncVars, err
 			}
 		}
 	}
 
 	// search recusively in all child groups.
-	if location == ChildrenAndCurrent || location == Children || location == All {
-		groupMs, err := g.GetGroupsM(ChildrenGrps)
+	if location.IsSet(Children) {
+		groups, err := g.GetGroupsM(AllChildrenGrps)
 		if err != nil {
 			return ncVars, err
 		}
-
-		for groupKey := range groupMs {
-			gps := groupMs.EqualRange(groupKey)
-			for _, gp := range gps {
-				varsM, err := gp.GetVarsM(ChildrenAndCurrent)
-
-				if err != nil {
-					return ncVars, err
-				}
-				keys, values := varsM.GetAllPair()
-				for i := 0; i < len(keys); i++ {
-					ncVars.Add(keys[i], values[i])
-				}
+		for _, gp := range groups.Values() {
+			if err := addVars(gp); err != nil {
+				return ncVars, err
 			}
 		}
 	}
@@ -560,18 +540,12 @@ func (g *Group) GetVars(location Location) map[string]sort.StringSlice {
 }
 
 // Get all Var objects with a given name.
-func (group Group) GetVars(name string, location Location /*Current*/) (SetV, error) {
-	tmpVar := NewSetV()
+func (group Group) GetVars(name string, location Location /*Current*/) ([]Var, error) {
 	ncVars, err := group.GetVarsM(location)
 	if err != nil {
-		return tmpVar, err
+		return nil, err
 	}
-	ret := ncVars.EqualRange(name)
-
-	for _, v := range ret {
-		tmpVar.Add(v)
-	}
-	return tmpVar, nil
+	return ncVars.EqualRange(name), nil
 }
 
 // Get the named Var object.
@@ -580,14 +554,58 @@ func (group Group) GetVar(name string, location Location /*Current*/) (Var, erro
 	if err != nil {
 		return NewVarNull(), err
 	}
-	ret := ncVars.EqualRange(name)
-
-	if len(ret) == 0 {
+	v, ok := ncVars.Get(name)
+	if !ok {
 		return NewVarNull(), nil
-	} else {
-		v := ret[0]
-		return v, nil
 	}
+	return v, nil
+}
+
+// SetUseProposedStandardName sets whether FindByStandardName matches the
+// 'proposed_standard_name' attribute instead of 'standard_name'.
+func (g *Group) SetUseProposedStandardName(use bool) {
+	g.useProposedStandardName = use
+}
+
+// UseProposedStandardName returns true if FindByStandardName matches the
+// 'proposed_standard_name' attribute instead of 'standard_name'.
+func (g *Group) UseProposedStandardName() bool {
+	return g.useProposedStandardName
+}
+
+// FindByStandardName gets the variables in location whose 'standard_name'
+// attribute, or 'proposed_standard_name' if UseProposedStandardName is set,
+// is name. Surrounding white space in the attribute is ignored, but a
+// standard name modifier such as "air_temperature standard_error" is part
+// of the name. Variables whose attribute is not text are skipped.
+func (g *Group) FindByStandardName(name string, location Location) ([]Var, error) {
+	if g.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke FindByStandardName on a Null group")
+	}
+	attName := "standard_name"
+	if g.useProposedStandardName {
+		attName = "proposed_standard_name"
+	}
+	ncVars, err := g.GetVarsM(location)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.Join(strings.Fields(name), " ")
+	var found []Var
+	for _, v := range ncVars.Values() {
+		att, err := v.GetAtt(attName)
+		if err != nil {
+			continue
+		}
+		value, err := att.Text()
+		if err != nil {
+			continue
+		}
+		if strings.Join(strings.Fields(value), " ") == name {
+			found = append(found, v)
+		}
+	}
+	return found, nil
 }
 
 // Add a new netCDF variable.
@@ -845,9 +863,9 @@ func (group Group) GetDimCount(location Location /*Current*/) (int, error) {
 	return ndims, nil
 }
 
-// Get the set of Dim objects.
-func (group Group) GetDimsM(location Location /*Current*/) (MultimapD, error) {
-	ncDims := NewMultimapD() // create a container to hold the Dim's.
+// Get the collection of Dim objects, ordered like GetVarsM.
+func (group Group) GetDimsM(location Location /*Current*/) (Dims, error) {
+	var ncDims Dims // create a container to hold the Dim's.
 
 	if group.IsNull() {
 		return ncDims, fmt.Errorf("error: attempt to invoke GetDimsM on a Null group")
@@ -931,31 +949,63 @@ func (group Group) GetDim(name string, location Location /*Current*/) (Dim, erro
 	if err != nil {
 		return NewDimNull(), err
 	}
-	ret := ncDims.EqualRange(name)
-	if len(ret) == 0 {
+	gp, ok := ncDims.Get(name) //if there are multiple, get the current first
+	if !ok {
 		return NewDimNull(), nil
-	} else {
-		gp := ret[0] //if there are multiple, get the current first
-		return gp, nil
 	}
+	return gp, nil
 }
 
 // Get all Dim objects with a given name.
 
-func (group Group) GetDims(name string, location Location) (SetD, error) {
-	ncSetD := NewSetD()
+func (group Group) GetDims(name string, location Location) ([]Dim, error) {
 	if group.IsNull() {
-		return ncSetD, fmt.Errorf("error: attempt to invoke GetGroups on a Null group")
+		return nil, fmt.Errorf("error: attempt to invoke GetDims on a Null group")
 	}
 	ncDims, err := group.GetDimsM(location)
 	if err != nil {
-		return ncSetD, err
+		return nil, err
+	}
+	return ncDims.EqualRange(name), nil
+}
+
+// UnlimitedDims gets the unlimited (record) dimensions. A netCDF-4 file may
+// have several, defined in any group; they are ordered like GetDimsM.
+func (g *Group) UnlimitedDims(location Location) ([]Dim, error) {
+	if g.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke UnlimitedDims on a Null group")
+	}
+
+	var groups []*Group
+	if location.IsSet(Current) {
+		groups = append(groups, g)
 	}
-	ret := ncDims.EqualRange(name)
-	for _, dimS := range ret {
-		ncSetD.Add(dimS)
+	if location.IsSet(Parents) {
+		parents, err := g.GetGroupsM(ParentsGrps)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, parents.Values()...)
+	}
+	if location.IsSet(Children) {
+		children, err := g.GetGroupsM(AllChildrenGrps)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, children.Values()...)
+	}
+
+	var dims []Dim
+	for _, gp := range groups {
+		_, unlimDimIds, err := NcInqUnlimdims(gp.id)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range unlimDimIds {
+			dims = append(dims, NewDim(*gp, id))
+		}
 	}
-	return ncSetD, nil
+	return dims, nil
 }
 
 // Add a new Dim object.
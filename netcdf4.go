@@ -3,6 +3,7 @@ package netcdf4
 // #cgo LDFLAGS: -lnetcdf
 // #include <stdlib.h>
 // #include <netcdf.h>
+// #include <netcdf_filter.h>
 import "C"
 import (
 	"fmt"
@@ -38,6 +39,9 @@ const (
 
 const NCUNLIMITED = C.NC_UNLIMITED
 
+// NCGLOBAL is the variable ID used for group (global) attributes.
+const NCGLOBAL = C.NC_GLOBAL
+
 // ID represents a ncId or groupid.
 type ID C.int
 
@@ -203,6 +207,54 @@ func NcInqGrps(ncId ID) (numGrps int, ncIds []ID, err error) {
 
 //nc_inq_grps(int ncid, int *numgrps, int *ncids);
 
+//nc_inq_format(int ncid, int *formatp);
+func ncInqFormat(ncId ID) (format FileFormat, err error) {
+	var cFormat C.int
+	err = NewError(C.nc_inq_format(C.int(ncId), &cFormat))
+	switch cFormat {
+	case C.NC_FORMAT_CLASSIC:
+		format = CLASSIC
+	case C.NC_FORMAT_64BIT_OFFSET:
+		format = CLASSIC64
+	case C.NC_FORMAT_NETCDF4:
+		format = NETCDF4
+	case C.NC_FORMAT_NETCDF4_CLASSIC:
+		format = NETCDF4CLASSIC
+	default:
+		format = UNKNOWN
+	}
+	return
+}
+
+//nc_inq_path(int ncid, size_t *pathlen, char *path);
+func ncInqPath(ncId ID) (string, error) {
+	var pathLen C.size_t
+	if err := NewError(C.nc_inq_path(C.int(ncId), &pathLen, nil)); err != nil {
+		return "", err
+	}
+	cPath := (*C.char)(C.malloc(pathLen + 1))
+	defer C.free(unsafe.Pointer(cPath))
+	if err := NewError(C.nc_inq_path(C.int(ncId), nil, cPath)); err != nil {
+		return "", err
+	}
+	return C.GoStringN(cPath, C.int(pathLen)), nil
+}
+
+// ncInqWritable reports whether the file was opened for writing, by
+// entering and leaving define mode.
+func ncInqWritable(ncId ID) (bool, error) {
+	switch status := C.nc_redef(C.int(ncId)); status {
+	case C.NC_NOERR:
+		return true, NewError(C.nc_enddef(C.int(ncId)))
+	case C.NC_EINDEFINE:
+		return true, nil
+	case C.NC_EPERM:
+		return false, nil
+	default:
+		return false, NewError(status)
+	}
+}
+
 func ncRedef(ncId ID) (err error) {
 	err = NewError(C.nc_redef(C.int(ncId)))
 	return
@@ -264,13 +316,30 @@ func ncInqUnlimdim(ncId ID) (unlimdimidp int, err error) {
 	return
 }
 
-//func NcInqUnlimdims(ncId ID) (nunlimdimsp, unlimdimidsp int, err error) {
-//	var cNunlimdimsp, cUnlimdimidsp C.int
-//	err = NewError(C.nc_inq_unlimdims(C.int(ncId), &cNunlimdimsp, &cUnlimdimidsp))
-//	nunlimdimsp = int(cNunlimdimsp)
-//	unlimdimidsp = int(cUnlimdimidsp)
-//	return
-//}
+/* Find the unlimited dimensions defined in a group (not its parents).
+ * netCDF-4 files may have several of them. */
+
+func NcInqUnlimdims(ncId ID) (nUnlimDims int, unlimDimIds []ID, err error) {
+	var cNumDims C.int
+	err = NewError(C.nc_inq_unlimdims(C.int(ncId), &cNumDims, nil))
+	if err != nil {
+		return
+	}
+	nUnlimDims = int(cNumDims)
+	if nUnlimDims == 0 {
+		return nUnlimDims, []ID(nil), nil
+	}
+	cDimIds := make([]C.int, nUnlimDims)
+	err = NewError(C.nc_inq_unlimdims(C.int(ncId), &cNumDims, &cDimIds[0]))
+	if err != nil {
+		return
+	}
+	unlimDimIds = make([]ID, nUnlimDims)
+	for i := 0; i < nUnlimDims; i++ {
+		unlimDimIds[i] = ID(cDimIds[i])
+	}
+	return
+}
 
 ///* Get a list of ids for all the variables in a group. */
 
@@ -334,15 +403,39 @@ func NcInqDimids(ncId ID, includeParents bool) (nDims int, dimIds []ID, err erro
 	return
 }
 
-///* Find all user-defined types for a location. This finds all
-// * user-defined types in a group. */
+/* Find all user-defined types for a location. This finds all
+ * user-defined types in a group. */
 
-//nc_inq_typeids(int ncid, int *ntypes, int *typeids);
-//
-///* Are two types equal? */
+func ncInqTypeids(ncId ID) (nTypes int, typeIds []NcType, err error) {
+	var cNumTypes C.int
+	err = NewError(C.nc_inq_typeids(C.int(ncId), &cNumTypes, nil))
+	if err != nil {
+		return
+	}
+	nTypes = int(cNumTypes)
+	if nTypes == 0 {
+		return nTypes, []NcType(nil), nil
+	}
+	cTypeIds := make([]C.int, nTypes)
+	err = NewError(C.nc_inq_typeids(C.int(ncId), &cNumTypes, &cTypeIds[0]))
+	if err != nil {
+		return
+	}
+	typeIds = make([]NcType, nTypes)
+	for i := 0; i < nTypes; i++ {
+		typeIds[i] = NcType(cTypeIds[i])
+	}
+	return
+}
 
-//nc_inq_type_equal(int ncid1, nc_type typeid1, int ncid2,
-//nc_type typeid2, int *equal);
+/* Are two types equal? */
+
+func ncInqTypeEqual(ncId1 ID, typeId1 NcType, ncId2 ID, typeId2 NcType) (equal bool, err error) {
+	var cEqual C.int
+	err = NewError(C.nc_inq_type_equal(C.int(ncId1), C.nc_type(typeId1), C.int(ncId2), C.nc_type(typeId2), &cEqual))
+	equal = cEqual != 0
+	return
+}
 
 /* Create a group. its ncId is returned as newId. */
 
@@ -355,6 +448,14 @@ func ncDefGrp(parentId ID, name string) (newId ID, err error) {
 	return
 }
 
+//nc_rename_grp(int grpid, const char *name);
+func NcRenameGrp(ncId ID, name string) (err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	err = NewError(C.nc_rename_grp(C.int(ncId), cName))
+	return
+}
+
 /* Given locid, find name of group. (Root group is named "/".) */
 
 func ncInqGrpname(ncId ID) (name string, err error) {
@@ -399,6 +500,18 @@ func ncInqGrpParent(ncId ID) (parentId ID, err error) {
 	return
 }
 
+/* Given a full name and ncid, find group ncid. A name starting with "/"
+ * is resolved from the root group, otherwise relative to ncId. */
+
+func ncInqGrpFullNcid(ncId ID, fullName string) (grpId ID, err error) {
+	cName := C.CString(fullName)
+	defer C.free(unsafe.Pointer(cName))
+	var id C.int
+	err = NewError(C.nc_inq_grp_full_ncid(C.int(ncId), cName, &id))
+	grpId = ID(id)
+	return
+}
+
 /* Begin _dim */
 
 /* Create a group. its ncId is returned as newId. */
@@ -476,7 +589,14 @@ func NcDefVar(ncId ID, name string, xtype NcType, dimIds []ID) (varId ID, err er
 //
 
 //nc_inq_varid(int ncid, const char *name, int *varidp);
-//
+func ncInqVarid(ncId ID, name string) (varId ID, err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	var id C.int
+	err = NewError(C.nc_inq_varid(C.int(ncId), cName, &id))
+	varId = ID(id)
+	return
+}
 
 func NcInqVarname(ncId ID, VarId ID) (name string, err error) {
 	cName := C.CString(string(make([]byte, C.NC_MAX_NAME+1)))
@@ -527,10 +647,20 @@ func NcInqVardimid(ncId ID, varId ID) (nDims int, dimIds []ID, err error) {
 //
 
 //nc_inq_varnatts(int ncid, int varid, int *nattsp);
-//
+func ncInqVarnatts(ncId ID, varId ID) (nAtts int, err error) {
+	var cNAtts C.int
+	err = NewError(C.nc_inq_varnatts(C.int(ncId), C.int(varId), &cNAtts))
+	nAtts = int(cNAtts)
+	return
+}
 
 //nc_rename_var(int ncid, int varid, const char *name);
-//
+func NcRenameVar(ncId ID, varId ID, name string) (err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	err = NewError(C.nc_rename_var(C.int(ncId), C.int(varId), cName))
+	return
+}
 
 //nc_copy_var(int ncid_in, int varid, int ncid_out);
 //
@@ -541,6 +671,498 @@ func NcInqVardimid(ncId ID, varId ID) (nDims int, dimIds []ID, err error) {
 
 /* End _var */
 
+/* Begin _att */
+
+//nc_inq_attname(int ncid, int varid, int attnum, char *name);
+func ncInqAttname(ncId ID, varId ID, attNum int) (name string, err error) {
+	cName := C.CString(string(make([]byte, C.NC_MAX_NAME+1)))
+	defer C.free(unsafe.Pointer(cName))
+	err = NewError(C.nc_inq_attname(C.int(ncId), C.int(varId), C.int(attNum), cName))
+	name = C.GoString(cName)
+	return
+}
+
+//nc_inq_atttype(int ncid, int varid, const char *name, nc_type *xtypep);
+func ncInqAtttype(ncId ID, varId ID, name string) (xtype NcType, err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	var cxtype C.nc_type
+	err = NewError(C.nc_inq_atttype(C.int(ncId), C.int(varId), cName, &cxtype))
+	xtype = NcType(cxtype)
+	return
+}
+
+//nc_inq_attlen(int ncid, int varid, const char *name, size_t *lenp);
+func ncInqAttlen(ncId ID, varId ID, name string) (attLen int, err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	var lenp C.size_t
+	err = NewError(C.nc_inq_attlen(C.int(ncId), C.int(varId), cName, &lenp))
+	attLen = int(lenp)
+	return
+}
+
+//nc_rename_att(int ncid, int varid, const char *name, const char *newname);
+func NcRenameAtt(ncId ID, varId ID, name string, newName string) (err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cNewName := C.CString(newName)
+	defer C.free(unsafe.Pointer(cNewName))
+	err = NewError(C.nc_rename_att(C.int(ncId), C.int(varId), cName, cNewName))
+	return
+}
+
+//nc_copy_att(int ncid_in, int varid_in, const char *name, int ncid_out, int varid_out);
+func ncCopyAtt(ncIdIn ID, varIdIn ID, name string, ncIdOut ID, varIdOut ID) (err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	err = NewError(C.nc_copy_att(C.int(ncIdIn), C.int(varIdIn), cName, C.int(ncIdOut), C.int(varIdOut)))
+	return
+}
+
+//nc_get_att_xxx(int ncid, int varid, const char *name, xxx *ip);
+// ncGetAtt reads all values of an attribute into data, a slice of a fixed
+// size numeric type holding exactly that many values. The values are
+// converted from the type of the attribute.
+func ncGetAtt(ncId ID, varId ID, name string, data interface{}) (err error) {
+	n, err := dataLen(data)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	ncid, varid := C.int(ncId), C.int(varId)
+	switch d := data.(type) {
+	case []int8:
+		err = NewError(C.nc_get_att_schar(ncid, varid, cName, (*C.schar)(unsafe.Pointer(&d[0]))))
+	case []uint8:
+		err = NewError(C.nc_get_att_uchar(ncid, varid, cName, (*C.uchar)(unsafe.Pointer(&d[0]))))
+	case []int16:
+		err = NewError(C.nc_get_att_short(ncid, varid, cName, (*C.short)(unsafe.Pointer(&d[0]))))
+	case []uint16:
+		err = NewError(C.nc_get_att_ushort(ncid, varid, cName, (*C.ushort)(unsafe.Pointer(&d[0]))))
+	case []int32:
+		err = NewError(C.nc_get_att_int(ncid, varid, cName, (*C.int)(unsafe.Pointer(&d[0]))))
+	case []uint32:
+		err = NewError(C.nc_get_att_uint(ncid, varid, cName, (*C.uint)(unsafe.Pointer(&d[0]))))
+	case []int64:
+		err = NewError(C.nc_get_att_longlong(ncid, varid, cName, (*C.longlong)(unsafe.Pointer(&d[0]))))
+	case []uint64:
+		err = NewError(C.nc_get_att_ulonglong(ncid, varid, cName, (*C.ulonglong)(unsafe.Pointer(&d[0]))))
+	case []float32:
+		err = NewError(C.nc_get_att_float(ncid, varid, cName, (*C.float)(unsafe.Pointer(&d[0]))))
+	case []float64:
+		err = NewError(C.nc_get_att_double(ncid, varid, cName, (*C.double)(unsafe.Pointer(&d[0]))))
+	default:
+		err = fmt.Errorf("error: unsupported data type %T", data)
+	}
+	return
+}
+
+//nc_get_att_text(int ncid, int varid, const char *name, char *ip);
+func ncGetAttText(ncId ID, varId ID, name string, data []byte) (err error) {
+	if len(data) == 0 {
+		return nil
+	}
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	err = NewError(C.nc_get_att_text(C.int(ncId), C.int(varId), cName, (*C.char)(unsafe.Pointer(&data[0]))))
+	return
+}
+
+//nc_get_att_string(int ncid, int varid, const char *name, char **ip);
+// ncGetAttString reads the n values of an NC_STRING attribute; the memory the
+// library allocates for them is released with nc_free_string.
+func ncGetAttString(ncId ID, varId ID, name string, n int) (data []string, err error) {
+	if n == 0 {
+		return []string{}, nil
+	}
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	ptrs := make([]*C.char, n)
+	err = NewError(C.nc_get_att_string(C.int(ncId), C.int(varId), cName, &ptrs[0]))
+	if err != nil {
+		return nil, err
+	}
+	defer C.nc_free_string(C.size_t(n), &ptrs[0])
+	data = make([]string, n)
+	for i, p := range ptrs {
+		if p != nil {
+			data[i] = C.GoString(p)
+		}
+	}
+	return data, nil
+}
+
+//nc_put_att_xxx(int ncid, int varid, const char *name, nc_type xtype, size_t len, const xxx *op);
+// ncPutAtt writes data, a slice of a fixed size numeric type, as an
+// attribute of type xtype; the values are converted to that type.
+func ncPutAtt(ncId ID, varId ID, name string, xtype NcType, data interface{}) (err error) {
+	n, err := dataLen(data)
+	if err != nil {
+		return err
+	}
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	ncid, varid, cType, cLen := C.int(ncId), C.int(varId), C.nc_type(xtype), C.size_t(n)
+	var p unsafe.Pointer
+	if n > 0 {
+		p = reflect.ValueOf(data).UnsafePointer()
+	}
+	switch data.(type) {
+	case []int8:
+		err = NewError(C.nc_put_att_schar(ncid, varid, cName, cType, cLen, (*C.schar)(p)))
+	case []uint8:
+		err = NewError(C.nc_put_att_uchar(ncid, varid, cName, cType, cLen, (*C.uchar)(p)))
+	case []int16:
+		err = NewError(C.nc_put_att_short(ncid, varid, cName, cType, cLen, (*C.short)(p)))
+	case []uint16:
+		err = NewError(C.nc_put_att_ushort(ncid, varid, cName, cType, cLen, (*C.ushort)(p)))
+	case []int32:
+		err = NewError(C.nc_put_att_int(ncid, varid, cName, cType, cLen, (*C.int)(p)))
+	case []uint32:
+		err = NewError(C.nc_put_att_uint(ncid, varid, cName, cType, cLen, (*C.uint)(p)))
+	case []int64:
+		err = NewError(C.nc_put_att_longlong(ncid, varid, cName, cType, cLen, (*C.longlong)(p)))
+	case []uint64:
+		err = NewError(C.nc_put_att_ulonglong(ncid, varid, cName, cType, cLen, (*C.ulonglong)(p)))
+	case []float32:
+		err = NewError(C.nc_put_att_float(ncid, varid, cName, cType, cLen, (*C.float)(p)))
+	case []float64:
+		err = NewError(C.nc_put_att_double(ncid, varid, cName, cType, cLen, (*C.double)(p)))
+	default:
+		err = fmt.Errorf("error: unsupported data type %T", data)
+	}
+	return
+}
+
+//nc_put_att_text(int ncid, int varid, const char *name, size_t len, const char *op);
+func ncPutAttText(ncId ID, varId ID, name string, text string) (err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cText := C.CString(text)
+	defer C.free(unsafe.Pointer(cText))
+	err = NewError(C.nc_put_att_text(C.int(ncId), C.int(varId), cName, C.size_t(len(text)), cText))
+	return
+}
+
+//nc_put_att_string(int ncid, int varid, const char *name, size_t len, const char **op);
+func ncPutAttString(ncId ID, varId ID, name string, data []string) (err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	ptrs := make([]*C.char, len(data)+1)
+	for i, str := range data {
+		ptrs[i] = C.CString(str)
+		defer C.free(unsafe.Pointer(ptrs[i]))
+	}
+	err = NewError(C.nc_put_att_string(C.int(ncId), C.int(varId), cName, C.size_t(len(data)), &ptrs[0]))
+	return
+}
+
+/* End _att */
+
+/* Begin _type */
+
+//nc_inq_type(int ncid, nc_type xtype, char *name, size_t *size);
+func ncInqTypeSize(ncId ID, xtype NcType) (size int, err error) {
+	var cSize C.size_t
+	err = NewError(C.nc_inq_type(C.int(ncId), C.nc_type(xtype), nil, &cSize))
+	size = int(cSize)
+	return
+}
+
+//nc_inq_typeid(int ncid, const char *name, nc_type *typeidp);
+func ncInqTypeid(ncId ID, name string) (xtype NcType, err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	var cxtype C.nc_type
+	err = NewError(C.nc_inq_typeid(C.int(ncId), cName, &cxtype))
+	xtype = NcType(cxtype)
+	return
+}
+
+//nc_inq_user_type(int ncid, nc_type xtype, char *name, size_t *size,
+//nc_type *base_nc_typep, size_t *nfieldsp, int *classp);
+func ncInqUserType(ncId ID, xtype NcType) (name string, size int, baseType NcType, nFields int, class NcType, err error) {
+	cName := C.CString(string(make([]byte, C.NC_MAX_NAME+1)))
+	defer C.free(unsafe.Pointer(cName))
+	var cSize, cNFields C.size_t
+	var cBaseType C.nc_type
+	var cClass C.int
+	err = NewError(C.nc_inq_user_type(C.int(ncId), C.nc_type(xtype), cName, &cSize, &cBaseType, &cNFields, &cClass))
+	name = C.GoString(cName)
+	size = int(cSize)
+	baseType = NcType(cBaseType)
+	nFields = int(cNFields)
+	class = NcType(cClass)
+	return
+}
+
+//nc_def_compound(int ncid, size_t size, const char *name, nc_type *typeidp);
+func ncDefCompound(ncId ID, size int, name string) (xtype NcType, err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	var cxtype C.nc_type
+	err = NewError(C.nc_def_compound(C.int(ncId), C.size_t(size), cName, &cxtype))
+	xtype = NcType(cxtype)
+	return
+}
+
+//nc_insert_array_compound(int ncid, nc_type xtype, const char *name,
+//size_t offset, nc_type field_typeid, int ndims, const int *dim_sizes);
+func ncInsertArrayCompound(ncId ID, xtype NcType, name string, offset int, fieldType NcType, dimSizes []int) (err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	if len(dimSizes) == 0 {
+		err = NewError(C.nc_insert_compound(C.int(ncId), C.nc_type(xtype), cName, C.size_t(offset), C.nc_type(fieldType)))
+		return
+	}
+	cDimSizes := make([]C.int, len(dimSizes))
+	for i, d := range dimSizes {
+		cDimSizes[i] = C.int(d)
+	}
+	err = NewError(C.nc_insert_array_compound(C.int(ncId), C.nc_type(xtype), cName, C.size_t(offset), C.nc_type(fieldType), C.int(len(cDimSizes)), &cDimSizes[0]))
+	return
+}
+
+//nc_inq_compound_field(int ncid, nc_type xtype, int fieldid, char *name,
+//size_t *offsetp, nc_type *field_typeidp, int *ndimsp, int *dim_sizesp);
+func ncInqCompoundField(ncId ID, xtype NcType, fieldId int) (name string, offset int, fieldType NcType, dimSizes []int, err error) {
+	cName := C.CString(string(make([]byte, C.NC_MAX_NAME+1)))
+	defer C.free(unsafe.Pointer(cName))
+	var cOffset C.size_t
+	var cFieldType C.nc_type
+	var cNDims C.int
+	cDimSizes := make([]C.int, C.NC_MAX_VAR_DIMS)
+	err = NewError(C.nc_inq_compound_field(C.int(ncId), C.nc_type(xtype), C.int(fieldId), cName, &cOffset, &cFieldType, &cNDims, &cDimSizes[0]))
+	name = C.GoString(cName)
+	offset = int(cOffset)
+	fieldType = NcType(cFieldType)
+	for i := 0; i < int(cNDims); i++ {
+		dimSizes = append(dimSizes, int(cDimSizes[i]))
+	}
+	return
+}
+
+//nc_def_vlen(int ncid, const char *name, nc_type base_typeid, nc_type *xtypep);
+func ncDefVlen(ncId ID, name string, baseType NcType) (xtype NcType, err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	var cxtype C.nc_type
+	err = NewError(C.nc_def_vlen(C.int(ncId), cName, C.nc_type(baseType), &cxtype))
+	xtype = NcType(cxtype)
+	return
+}
+
+//nc_def_opaque(int ncid, size_t size, const char *name, nc_type *xtypep);
+func ncDefOpaque(ncId ID, size int, name string) (xtype NcType, err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	var cxtype C.nc_type
+	err = NewError(C.nc_def_opaque(C.int(ncId), C.size_t(size), cName, &cxtype))
+	xtype = NcType(cxtype)
+	return
+}
+
+//nc_def_enum(int ncid, nc_type base_typeid, const char *name, nc_type *typeidp);
+func ncDefEnum(ncId ID, baseType NcType, name string) (xtype NcType, err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	var cxtype C.nc_type
+	err = NewError(C.nc_def_enum(C.int(ncId), C.nc_type(baseType), cName, &cxtype))
+	xtype = NcType(cxtype)
+	return
+}
+
+//nc_insert_enum(int ncid, nc_type xtype, const char *name, const void *value);
+func ncInsertEnum(ncId ID, xtype NcType, name string, value []byte) (err error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	err = NewError(C.nc_insert_enum(C.int(ncId), C.nc_type(xtype), cName, unsafe.Pointer(&value[0])))
+	return
+}
+
+//nc_inq_enum_member(int ncid, nc_type xtype, int idx, char *name, void *value);
+func ncInqEnumMember(ncId ID, xtype NcType, idx int) (name string, value []byte, err error) {
+	cName := C.CString(string(make([]byte, C.NC_MAX_NAME+1)))
+	defer C.free(unsafe.Pointer(cName))
+	value = make([]byte, 8) // large enough for any integer base type
+	err = NewError(C.nc_inq_enum_member(C.int(ncId), C.nc_type(xtype), C.int(idx), cName, unsafe.Pointer(&value[0])))
+	name = C.GoString(cName)
+	return
+}
+
+/* End _type */
+
+/* Begin var storage */
+
+//nc_inq_var_chunking(int ncid, int varid, int *storagep, size_t *chunksizesp);
+func ncInqVarChunking(ncId ID, varId ID, nDims int) (chunked bool, chunkSizes []int, err error) {
+	var cStorage C.int
+	cChunkSizes := make([]C.size_t, nDims+1) // never empty, for scalars
+	err = NewError(C.nc_inq_var_chunking(C.int(ncId), C.int(varId), &cStorage, &cChunkSizes[0]))
+	chunked = cStorage == C.NC_CHUNKED
+	if chunked {
+		chunkSizes = make([]int, nDims)
+		for i := range chunkSizes {
+			chunkSizes[i] = int(cChunkSizes[i])
+		}
+	}
+	return
+}
+
+//nc_def_var_chunking(int ncid, int varid, int storage, const size_t *chunksizesp);
+func ncDefVarChunking(ncId ID, varId ID, chunkSizes []int) (err error) {
+	err = NewError(C.nc_def_var_chunking(C.int(ncId), C.int(varId), C.NC_CHUNKED, sizeTPtr(toSizeT(chunkSizes))))
+	return
+}
+
+//nc_inq_var_deflate(int ncid, int varid, int *shufflep, int *deflatep, int *deflate_levelp);
+func ncInqVarDeflate(ncId ID, varId ID) (shuffle bool, deflate bool, level int, err error) {
+	var cShuffle, cDeflate, cLevel C.int
+	err = NewError(C.nc_inq_var_deflate(C.int(ncId), C.int(varId), &cShuffle, &cDeflate, &cLevel))
+	shuffle, deflate, level = cShuffle != 0, cDeflate != 0, int(cLevel)
+	return
+}
+
+//nc_def_var_deflate(int ncid, int varid, int shuffle, int deflate, int deflate_level);
+func ncDefVarDeflate(ncId ID, varId ID, shuffle bool, deflate bool, level int) (err error) {
+	cShuffle, cDeflate := C.int(0), C.int(0)
+	if shuffle {
+		cShuffle = 1
+	}
+	if deflate {
+		cDeflate = 1
+	}
+	err = NewError(C.nc_def_var_deflate(C.int(ncId), C.int(varId), cShuffle, cDeflate, C.int(level)))
+	return
+}
+
+//nc_inq_var_fletcher32(int ncid, int varid, int *fletcher32p);
+func ncInqVarFletcher32(ncId ID, varId ID) (fletcher32 bool, err error) {
+	var cFletcher32 C.int
+	err = NewError(C.nc_inq_var_fletcher32(C.int(ncId), C.int(varId), &cFletcher32))
+	fletcher32 = cFletcher32 != 0
+	return
+}
+
+//nc_def_var_fletcher32(int ncid, int varid, int fletcher32);
+func ncDefVarFletcher32(ncId ID, varId ID) (err error) {
+	err = NewError(C.nc_def_var_fletcher32(C.int(ncId), C.int(varId), 1))
+	return
+}
+
+//nc_inq_var_endian(int ncid, int varid, int *endianp);
+func ncInqVarEndian(ncId ID, varId ID) (endian int, err error) {
+	var cEndian C.int
+	err = NewError(C.nc_inq_var_endian(C.int(ncId), C.int(varId), &cEndian))
+	endian = int(cEndian)
+	return
+}
+
+//nc_def_var_endian(int ncid, int varid, int endian);
+func ncDefVarEndian(ncId ID, varId ID, endian int) (err error) {
+	err = NewError(C.nc_def_var_endian(C.int(ncId), C.int(varId), C.int(endian)))
+	return
+}
+
+//nc_inq_var_fill(int ncid, int varid, int *no_fill, void *fill_valuep);
+func ncInqVarNoFill(ncId ID, varId ID) (noFill bool, err error) {
+	var cNoFill C.int
+	err = NewError(C.nc_inq_var_fill(C.int(ncId), C.int(varId), &cNoFill, nil))
+	noFill = cNoFill != 0
+	return
+}
+
+//nc_def_var_fill(int ncid, int varid, int no_fill, const void *fill_value);
+func ncDefVarNoFill(ncId ID, varId ID) (err error) {
+	err = NewError(C.nc_def_var_fill(C.int(ncId), C.int(varId), 1, nil))
+	return
+}
+
+//nc_inq_var_filter_ids(int ncid, int varid, size_t *nfilters, unsigned int *filterids);
+func ncInqVarFilterIds(ncId ID, varId ID) (ids []uint, err error) {
+	var n C.size_t
+	if err = NewError(C.nc_inq_var_filter_ids(C.int(ncId), C.int(varId), &n, nil)); err != nil || n == 0 {
+		return nil, err
+	}
+	cIds := make([]C.uint, n)
+	if err = NewError(C.nc_inq_var_filter_ids(C.int(ncId), C.int(varId), &n, &cIds[0])); err != nil {
+		return nil, err
+	}
+	ids = make([]uint, n)
+	for i, id := range cIds {
+		ids[i] = uint(id)
+	}
+	return ids, nil
+}
+
+//nc_inq_var_filter_info(int ncid, int varid, unsigned int id, size_t *nparams, unsigned int *params);
+func ncInqVarFilterInfo(ncId ID, varId ID, filterId uint) (params []uint, err error) {
+	var n C.size_t
+	if err = NewError(C.nc_inq_var_filter_info(C.int(ncId), C.int(varId), C.uint(filterId), &n, nil)); err != nil || n == 0 {
+		return nil, err
+	}
+	cParams := make([]C.uint, n)
+	if err = NewError(C.nc_inq_var_filter_info(C.int(ncId), C.int(varId), C.uint(filterId), &n, &cParams[0])); err != nil {
+		return nil, err
+	}
+	params = make([]uint, n)
+	for i, p := range cParams {
+		params[i] = uint(p)
+	}
+	return params, nil
+}
+
+//nc_def_var_filter(int ncid, int varid, unsigned int id, size_t nparams, const unsigned int *parms);
+func ncDefVarFilter(ncId ID, varId ID, filterId uint, params []uint) (err error) {
+	cParams := make([]C.uint, len(params))
+	for i, p := range params {
+		cParams[i] = C.uint(p)
+	}
+	var ptr *C.uint
+	if len(cParams) > 0 {
+		ptr = &cParams[0]
+	}
+	err = NewError(C.nc_def_var_filter(C.int(ncId), C.int(varId), C.uint(filterId), C.size_t(len(cParams)), ptr))
+	return
+}
+
+//nc_inq_var_szip(int ncid, int varid, int *options_maskp, int *pixels_per_blockp);
+func ncInqVarSzip(ncId ID, varId ID) (optionsMask int, pixelsPerBlock int, err error) {
+	var cMask, cPixels C.int
+	err = NewError(C.nc_inq_var_szip(C.int(ncId), C.int(varId), &cMask, &cPixels))
+	optionsMask, pixelsPerBlock = int(cMask), int(cPixels)
+	return
+}
+
+//nc_def_var_szip(int ncid, int varid, int options_mask, int pixels_per_block);
+func ncDefVarSzip(ncId ID, varId ID, optionsMask int, pixelsPerBlock int) (err error) {
+	err = NewError(C.nc_def_var_szip(C.int(ncId), C.int(varId), C.int(optionsMask), C.int(pixelsPerBlock)))
+	return
+}
+
+//nc_inq_var_quantize(int ncid, int varid, int *quantize_modep, int *nsdp);
+func ncInqVarQuantize(ncId ID, varId ID) (mode int, nsd int, err error) {
+	var cMode, cNsd C.int
+	err = NewError(C.nc_inq_var_quantize(C.int(ncId), C.int(varId), &cMode, &cNsd))
+	mode, nsd = int(cMode), int(cNsd)
+	return
+}
+
+//nc_def_var_quantize(int ncid, int varid, int quantize_mode, int nsd);
+func ncDefVarQuantize(ncId ID, varId ID, mode int, nsd int) (err error) {
+	err = NewError(C.nc_def_var_quantize(C.int(ncId), C.int(varId), C.int(mode), C.int(nsd)))
+	return
+}
+
+/* End var storage */
+
 ///* Write entire var of any type. */
 
 //nc_put_var(int ncid, int varid,  const void *op);
@@ -592,3 +1214,229 @@ func NcPutVarDouble(ncId ID, VarId ID, data interface{}) (err error) {
 //nc_get_var_ulonglong(int ncid, int varid, unsigned long long *ip);
 //nc_put_var_string(int ncid, int varid, const char **op);
 //nc_get_var_string(int ncid, int varid, char **ip);
+
+/* Begin {put,get}_vara */
+
+// sizeTPtr returns a C pointer to the first element of s, nil when s is empty
+// (as for scalar variables).
+func sizeTPtr(s []SizeT) *C.size_t {
+	if len(s) == 0 {
+		return nil
+	}
+	return (*C.size_t)(unsafe.Pointer(&s[0]))
+}
+
+// toSizeT converts Go indices or lengths to SizeT.
+func toSizeT(s []int) []SizeT {
+	r := make([]SizeT, len(s))
+	for i, n := range s {
+		r[i] = SizeT(n)
+	}
+	return r
+}
+
+// dataLen returns the number of values held by the slice data.
+func dataLen(data interface{}) (int, error) {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("error: data must be a slice, got %T", data)
+	}
+	return rv.Len(), nil
+}
+
+// NcPutVara writes an array of values to a variable. The typed C function is
+// chosen from the Go type of data, a slice of a fixed size numeric type, and
+// the library converts the values to the type of the variable. start and
+// count must have one entry per dimension of the variable.
+func NcPutVara(ncId ID, varId ID, start, count []SizeT, data interface{}) (err error) {
+	n, err := dataLen(data)
+	if err != nil {
+		return err
+	}
+	want := 1
+	for _, c := range count {
+		want *= int(c)
+	}
+	if n != want {
+		return fmt.Errorf("error: data length %d does not match count %v", n, count)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	ncid, varid := C.int(ncId), C.int(varId)
+	cStart, cCount := sizeTPtr(start), sizeTPtr(count)
+	switch d := data.(type) {
+	case []int8:
+		err = NewError(C.nc_put_vara_schar(ncid, varid, cStart, cCount, (*C.schar)(unsafe.Pointer(&d[0]))))
+	case []uint8:
+		err = NewError(C.nc_put_vara_uchar(ncid, varid, cStart, cCount, (*C.uchar)(unsafe.Pointer(&d[0]))))
+	case []int16:
+		err = NewError(C.nc_put_vara_short(ncid, varid, cStart, cCount, (*C.short)(unsafe.Pointer(&d[0]))))
+	case []uint16:
+		err = NewError(C.nc_put_vara_ushort(ncid, varid, cStart, cCount, (*C.ushort)(unsafe.Pointer(&d[0]))))
+	case []int32:
+		err = NewError(C.nc_put_vara_int(ncid, varid, cStart, cCount, (*C.int)(unsafe.Pointer(&d[0]))))
+	case []uint32:
+		err = NewError(C.nc_put_vara_uint(ncid, varid, cStart, cCount, (*C.uint)(unsafe.Pointer(&d[0]))))
+	case []int64:
+		err = NewError(C.nc_put_vara_longlong(ncid, varid, cStart, cCount, (*C.longlong)(unsafe.Pointer(&d[0]))))
+	case []uint64:
+		err = NewError(C.nc_put_vara_ulonglong(ncid, varid, cStart, cCount, (*C.ulonglong)(unsafe.Pointer(&d[0]))))
+	case []float32:
+		err = NewError(C.nc_put_vara_float(ncid, varid, cStart, cCount, (*C.float)(unsafe.Pointer(&d[0]))))
+	case []float64:
+		err = NewError(C.nc_put_vara_double(ncid, varid, cStart, cCount, (*C.double)(unsafe.Pointer(&d[0]))))
+	default:
+		err = fmt.Errorf("error: unsupported data type %T", data)
+	}
+	return
+}
+
+// NcGetVara reads an array of values from a variable into data, a slice of a
+// fixed size numeric type that holds exactly the values selected by start
+// and count. The library converts the values from the type of the variable.
+func NcGetVara(ncId ID, varId ID, start, count []SizeT, data interface{}) (err error) {
+	n, err := dataLen(data)
+	if err != nil {
+		return err
+	}
+	want := 1
+	for _, c := range count {
+		want *= int(c)
+	}
+	if n != want {
+		return fmt.Errorf("error: data length %d does not match count %v", n, count)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	ncid, varid := C.int(ncId), C.int(varId)
+	cStart, cCount := sizeTPtr(start), sizeTPtr(count)
+	switch d := data.(type) {
+	case []int8:
+		err = NewError(C.nc_get_vara_schar(ncid, varid, cStart, cCount, (*C.schar)(unsafe.Pointer(&d[0]))))
+	case []uint8:
+		err = NewError(C.nc_get_vara_uchar(ncid, varid, cStart, cCount, (*C.uchar)(unsafe.Pointer(&d[0]))))
+	case []int16:
+		err = NewError(C.nc_get_vara_short(ncid, varid, cStart, cCount, (*C.short)(unsafe.Pointer(&d[0]))))
+	case []uint16:
+		err = NewError(C.nc_get_vara_ushort(ncid, varid, cStart, cCount, (*C.ushort)(unsafe.Pointer(&d[0]))))
+	case []int32:
+		err = NewError(C.nc_get_vara_int(ncid, varid, cStart, cCount, (*C.int)(unsafe.Pointer(&d[0]))))
+	case []uint32:
+		err = NewError(C.nc_get_vara_uint(ncid, varid, cStart, cCount, (*C.uint)(unsafe.Pointer(&d[0]))))
+	case []int64:
+		err = NewError(C.nc_get_vara_longlong(ncid, varid, cStart, cCount, (*C.longlong)(unsafe.Pointer(&d[0]))))
+	case []uint64:
+		err = NewError(C.nc_get_vara_ulonglong(ncid, varid, cStart, cCount, (*C.ulonglong)(unsafe.Pointer(&d[0]))))
+	case []float32:
+		err = NewError(C.nc_get_vara_float(ncid, varid, cStart, cCount, (*C.float)(unsafe.Pointer(&d[0]))))
+	case []float64:
+		err = NewError(C.nc_get_vara_double(ncid, varid, cStart, cCount, (*C.double)(unsafe.Pointer(&d[0]))))
+	default:
+		err = fmt.Errorf("error: unsupported data type %T", data)
+	}
+	return
+}
+
+//nc_get_vara_string(int ncid, int varid, const size_t *startp, const size_t *countp, char **ip);
+// ncGetVaraString reads NC_STRING values; the memory the library allocates
+// for them is released with nc_free_string.
+func ncGetVaraString(ncId ID, varId ID, start, count []SizeT) (data []string, err error) {
+	n := 1
+	for _, c := range count {
+		n *= int(c)
+	}
+	if n == 0 {
+		return []string{}, nil
+	}
+	ptrs := make([]*C.char, n)
+	err = NewError(C.nc_get_vara_string(C.int(ncId), C.int(varId), sizeTPtr(start), sizeTPtr(count), &ptrs[0]))
+	if err != nil {
+		return nil, err
+	}
+	defer C.nc_free_string(C.size_t(n), &ptrs[0])
+	data = make([]string, n)
+	for i, p := range ptrs {
+		if p != nil {
+			data[i] = C.GoString(p)
+		}
+	}
+	return data, nil
+}
+
+//nc_put_vara_string(int ncid, int varid, const size_t *startp, const size_t *countp, const char **op);
+func ncPutVaraString(ncId ID, varId ID, start, count []SizeT, data []string) (err error) {
+	n := 1
+	for _, c := range count {
+		n *= int(c)
+	}
+	if len(data) != n {
+		return fmt.Errorf("error: data length %d does not match count %v", len(data), count)
+	}
+	if n == 0 {
+		return nil
+	}
+	ptrs := make([]*C.char, n)
+	for i, str := range data {
+		ptrs[i] = C.CString(str)
+		defer C.free(unsafe.Pointer(ptrs[i]))
+	}
+	err = NewError(C.nc_put_vara_string(C.int(ncId), C.int(varId), sizeTPtr(start), sizeTPtr(count), &ptrs[0]))
+	return
+}
+
+//nc_get_vara_text(int ncid, int varid, const size_t *startp, const size_t *countp, char *ip);
+func ncGetVaraText(ncId ID, varId ID, start, count []SizeT, data []byte) (err error) {
+	if len(data) == 0 {
+		return nil
+	}
+	err = NewError(C.nc_get_vara_text(C.int(ncId), C.int(varId), sizeTPtr(start), sizeTPtr(count), (*C.char)(unsafe.Pointer(&data[0]))))
+	return
+}
+
+//nc_put_vara_text(int ncid, int varid, const size_t *startp, const size_t *countp, const char *op);
+func ncPutVaraText(ncId ID, varId ID, start, count []SizeT, data []byte) (err error) {
+	if len(data) == 0 {
+		return nil
+	}
+	err = NewError(C.nc_put_vara_text(C.int(ncId), C.int(varId), sizeTPtr(start), sizeTPtr(count), (*C.char)(unsafe.Pointer(&data[0]))))
+	return
+}
+
+//nc_get_vara(int ncid, int varid, const size_t *startp, const size_t *countp, void *ip);
+// ncGetVaraRaw reads values in the memory layout of the variable type into buf.
+func ncGetVaraRaw(ncId ID, varId ID, start, count []SizeT, buf []byte) (err error) {
+	var p unsafe.Pointer
+	if len(buf) > 0 {
+		p = unsafe.Pointer(&buf[0])
+	}
+	err = NewError(C.nc_get_vara(C.int(ncId), C.int(varId), sizeTPtr(start), sizeTPtr(count), p))
+	return
+}
+
+//nc_put_vara(int ncid, int varid, const size_t *startp, const size_t *countp, const void *op);
+// ncPutVaraRaw writes values held in the memory layout of the variable type.
+func ncPutVaraRaw(ncId ID, varId ID, start, count []SizeT, buf []byte) (err error) {
+	var p unsafe.Pointer
+	if len(buf) > 0 {
+		p = unsafe.Pointer(&buf[0])
+	}
+	err = NewError(C.nc_put_vara(C.int(ncId), C.int(varId), sizeTPtr(start), sizeTPtr(count), p))
+	return
+}
+
+//nc_reclaim_data(int ncid, nc_type xtypeid, void* memory, size_t nelems);
+// ncReclaimData frees the strings and vlens the library allocated while
+// reading n values of xtype into buf. buf itself is left alone.
+func ncReclaimData(ncId ID, xtype NcType, buf []byte, n int) (err error) {
+	if len(buf) == 0 {
+		return nil
+	}
+	err = NewError(C.nc_reclaim_data(C.int(ncId), C.nc_type(xtype), unsafe.Pointer(&buf[0]), C.size_t(n)))
+	return
+}
+
+/* End {put,get}_vara */
@@ -0,0 +1,364 @@
+package netcdf4
+
+import (
+	"fmt"
+	"math"
+)
+
+// earthRadius is the mean radius of the Earth in km.
+const earthRadius = 6371.0088
+
+// LatLon is a position in degrees.
+type LatLon struct {
+	Lat, Lon float64
+}
+
+// PointSeries is the data of a variable at the grid cell nearest a point,
+// as returned by Group.ExtractPoints.
+type PointSeries struct {
+	// Point is the requested position and Cell the position of the nearest
+	// grid cell, as its coordinates hold it.
+	Point, Cell LatLon
+	// Distance is the great circle distance from Point to Cell in km.
+	Distance float64
+	// Index holds the indices of the cell along the horizontal dimensions,
+	// in the order of the variable's dimensions.
+	Index []int
+	// Values holds the unpacked values at the cell, NaN for missing
+	// values, along the other dimensions of the variable, named by Dims;
+	// for a (time, lat, lon) variable it is a time series.
+	Values *Array[float64]
+	Dims   []string
+}
+
+// pointTreeKey identifies the latitude and longitude of a pointTree.
+type pointTreeKey struct {
+	lat, lon [2]ID
+}
+
+// pointTree is a KD-tree over the grid points of latitude and longitude
+// coordinates, as unit vectors so that the nearest point in 3-D is the
+// nearest on the sphere, whatever the longitude convention.
+type pointTree struct {
+	xyz   [][3]float64 // the grid points, by flat index
+	cells []LatLon     // their coordinates
+	order []int        // the flat indices of the valid points, in tree order
+	split []int8       // the split axis of each node, indexed like order
+	// dims are the IDs of the horizontal dimensions and shape their sizes;
+	// a flat index runs over them in that order.
+	dims  []ID
+	shape []int
+}
+
+// ExtractPoints reads the variable varName at the grid cells nearest to
+// points, for instance at station locations. The latitude and longitude are
+// found among its Coordinates as for Var.SubsetBBox; they may be 1-D
+// coordinate variables, 2-D curvilinear coordinates or 1-D on a single
+// dimension, such as the nodes of an unstructured mesh. Grid points with a
+// missing latitude or longitude are never chosen.
+//
+// The nearest cell is found with a KD-tree over the grid, which is built on
+// the first call and, for a file opened with File.Open, kept until the file
+// is closed: later calls from any group of the file reuse it for variables
+// with the same latitude and longitude, whatever their other dimensions. The
+// tree goes stale if the coordinates are rewritten while the file is open;
+// reopen the file after doing so.
+func (g *Group) ExtractPoints(varName string, points []LatLon) ([]PointSeries, error) {
+	if g.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke ExtractPoints on a Null group")
+	}
+	v, ok := findVar(g, varName)
+	if !ok {
+		return nil, fmt.Errorf("error: no variable %q", varName)
+	}
+	tree, err := g.pointTree(v)
+	if err != nil {
+		return nil, err
+	}
+	axes, err := tree.axes(v)
+	if err != nil {
+		return nil, err
+	}
+
+	shape, err := v.Shape()
+	if err != nil {
+		return nil, err
+	}
+	dims, err := v.GetDims()
+	if err != nil {
+		return nil, err
+	}
+	horizontal := map[int]bool{}
+	for _, axis := range axes {
+		horizontal[axis] = true
+	}
+	var outShape []int
+	var outDims []string
+	for i, dim := range dims {
+		if horizontal[i] {
+			continue
+		}
+		name, err := dim.Name()
+		if err != nil {
+			return nil, err
+		}
+		outShape = append(outShape, shape[i])
+		outDims = append(outDims, name)
+	}
+
+	series := make([]PointSeries, len(points))
+	for k, pt := range points {
+		if !(pt.Lat >= -90 && pt.Lat <= 90) || math.IsNaN(pt.Lon) || math.IsInf(pt.Lon, 0) {
+			return nil, fmt.Errorf("error: invalid point %v", pt)
+		}
+		q := unitVector(pt.Lat, pt.Lon)
+		p, chord := tree.nearest(q)
+		if p < 0 {
+			return nil, fmt.Errorf("error: %s has no valid grid points", varName)
+		}
+
+		s := PointSeries{Point: pt, Index: make([]int, len(axes)), Dims: outDims}
+		s.Distance = 2 * math.Asin(math.Min(chord/2, 1)) * earthRadius
+		s.Cell = tree.cells[p]
+
+		start, count := make([]int, len(shape)), append([]int{}, shape...)
+		rest := p
+		for i := len(axes) - 1; i >= 0; i-- {
+			idx := rest % tree.shape[i]
+			rest /= tree.shape[i]
+			start[axes[i]], count[axes[i]] = idx, 1
+		}
+		for i, axis := range axes {
+			s.Index[i] = start[axis]
+		}
+		values, err := v.ReadUnpackedSlab(start, count)
+		if err != nil {
+			return nil, err
+		}
+		if s.Values, err = values.Reshape(outShape...); err != nil {
+			return nil, err
+		}
+		series[k] = s
+	}
+	return series, nil
+}
+
+// pointTree returns the KD-tree over the latitude and longitude of v,
+// building it if it is not in the cache of the group's file.
+func (g *Group) pointTree(v Var) (*pointTree, error) {
+	lonVar, latVar, err := v.lonLat()
+	if err != nil {
+		return nil, err
+	}
+	key := pointTreeKey{lat: [2]ID{latVar.groupId, latVar.myId}, lon: [2]ID{lonVar.groupId, lonVar.myId}}
+	f, cached := openFile(g.id)
+	if cached {
+		openFilesMu.Lock()
+		tree, ok := f.pointTrees[key]
+		openFilesMu.Unlock()
+		if ok {
+			return tree, nil
+		}
+	}
+
+	dims, err := v.GetDims()
+	if err != nil {
+		return nil, err
+	}
+	latAxes, err := dimAxes(dims, latVar)
+	if err != nil {
+		return nil, err
+	}
+	lonAxes, err := dimAxes(dims, lonVar)
+	if err != nil {
+		return nil, err
+	}
+	lat, err := latVar.ReadUnpacked()
+	if err != nil {
+		return nil, err
+	}
+	lon, err := lonVar.ReadUnpacked()
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &pointTree{}
+	switch {
+	case len(latAxes) == 1 && len(lonAxes) == 1 && latAxes[0] != lonAxes[0]:
+		// rectilinear: every combination of a latitude and a longitude
+		tree.dims = []ID{dims[latAxes[0]].id, dims[lonAxes[0]].id}
+		tree.shape = []int{lat.Len(), lon.Len()}
+		for _, y := range lat.Data() {
+			for _, x := range lon.Data() {
+				tree.cells = append(tree.cells, LatLon{y, x})
+			}
+		}
+	case len(latAxes) == len(lonAxes) && len(latAxes) <= 2 && len(latAxes) > 0:
+		for i := range latAxes {
+			if latAxes[i] != lonAxes[i] {
+				return nil, fmt.Errorf("error: latitude and longitude have different dimensions")
+			}
+			tree.dims = append(tree.dims, dims[latAxes[i]].id)
+		}
+		tree.shape = lat.Shape()
+		tree.cells = make([]LatLon, lat.Len())
+		for i := range tree.cells {
+			tree.cells[i] = LatLon{lat.Data()[i], lon.Data()[i]}
+		}
+	default:
+		return nil, fmt.Errorf("error: ExtractPoints needs 1-D or 2-D latitude and longitude")
+	}
+	tree.build()
+
+	if cached {
+		openFilesMu.Lock()
+		if f.pointTrees == nil {
+			f.pointTrees = map[pointTreeKey]*pointTree{}
+		}
+		f.pointTrees[key] = tree
+		openFilesMu.Unlock()
+	}
+	return tree, nil
+}
+
+// axes returns the positions in v of the horizontal dimensions of the tree,
+// in tree order.
+func (t *pointTree) axes(v Var) ([]int, error) {
+	dims, err := v.GetDims()
+	if err != nil {
+		return nil, err
+	}
+	axes := make([]int, len(t.dims))
+	for i, id := range t.dims {
+		axes[i] = -1
+		for j, dim := range dims {
+			if dim.id == id {
+				axes[i] = j
+			}
+		}
+		if axes[i] < 0 {
+			return nil, fmt.Errorf("error: the variable is not along the dimensions of its latitude and longitude")
+		}
+	}
+	return axes, nil
+}
+
+// unitVector returns the unit vector of a position; it holds NaN if the
+// position is missing.
+func unitVector(lat, lon float64) [3]float64 {
+	phi, lambda := lat*math.Pi/180, lon*math.Pi/180
+	return [3]float64{math.Cos(phi) * math.Cos(lambda), math.Cos(phi) * math.Sin(lambda), math.Sin(phi)}
+}
+
+// build computes the unit vectors of the cells and arranges the valid ones
+// into an implicit KD-tree: the node of a range of order is at its middle,
+// with the points below it along its split axis before it and the others
+// after.
+func (t *pointTree) build() {
+	t.xyz = make([][3]float64, len(t.cells))
+	for i, c := range t.cells {
+		t.xyz[i] = unitVector(c.Lat, c.Lon)
+	}
+	for i, p := range t.xyz {
+		if !math.IsNaN(p[0] + p[1] + p[2]) {
+			t.order = append(t.order, i)
+		}
+	}
+	t.split = make([]int8, len(t.order))
+	t.buildRange(0, len(t.order))
+}
+
+func (t *pointTree) buildRange(lo, hi int) {
+	if hi-lo <= 1 {
+		return
+	}
+	// split along the axis of largest spread
+	var minP, maxP [3]float64
+	for d := range 3 {
+		minP[d], maxP[d] = math.Inf(1), math.Inf(-1)
+	}
+	for _, i := range t.order[lo:hi] {
+		for d, x := range t.xyz[i] {
+			minP[d], maxP[d] = math.Min(minP[d], x), math.Max(maxP[d], x)
+		}
+	}
+	axis := 0
+	for d := 1; d < 3; d++ {
+		if maxP[d]-minP[d] > maxP[axis]-minP[axis] {
+			axis = d
+		}
+	}
+	mid := (lo + hi) / 2
+	t.selectNth(lo, hi, mid, axis)
+	t.split[mid] = int8(axis)
+	t.buildRange(lo, mid)
+	t.buildRange(mid+1, hi)
+}
+
+// selectNth reorders order[lo:hi] so that the point at n is the one that
+// would be there if they were sorted along axis, with none greater before
+// it and none smaller after.
+func (t *pointTree) selectNth(lo, hi, n, axis int) {
+	key := func(k int) float64 { return t.xyz[t.order[k]][axis] }
+	for hi-lo > 1 {
+		// partition around the middle value
+		pivot := key((lo + hi) / 2)
+		i, j := lo, hi-1
+		for i <= j {
+			for key(i) < pivot {
+				i++
+			}
+			for key(j) > pivot {
+				j--
+			}
+			if i <= j {
+				t.order[i], t.order[j] = t.order[j], t.order[i]
+				i++
+				j--
+			}
+		}
+		switch {
+		case n <= j:
+			hi = j + 1
+		case n >= i:
+			lo = i
+		default:
+			return
+		}
+	}
+}
+
+// nearest returns the flat index of the grid point nearest q and its chord
+// distance on the unit sphere; the index is -1 if there are no points.
+func (t *pointTree) nearest(q [3]float64) (int, float64) {
+	best, bestDist := -1, math.Inf(1)
+	var search func(lo, hi int)
+	search = func(lo, hi int) {
+		if lo >= hi {
+			return
+		}
+		mid := (lo + hi) / 2
+		p := t.xyz[t.order[mid]]
+		dist := 0.0
+		for d := range 3 {
+			dist += (q[d] - p[d]) * (q[d] - p[d])
+		}
+		if dist < bestDist {
+			best, bestDist = t.order[mid], dist
+		}
+		diff := q[t.split[mid]] - p[t.split[mid]]
+		if diff < 0 {
+			search(lo, mid)
+			if diff*diff < bestDist {
+				search(mid+1, hi)
+			}
+		} else {
+			search(mid+1, hi)
+			if diff*diff < bestDist {
+				search(lo, mid)
+			}
+		}
+	}
+	search(0, len(t.order))
+	return best, math.Sqrt(bestDist)
+}
@@ -0,0 +1,164 @@
+package netcdf4
+
+import "fmt"
+
+// RecordWriter appends records to several variables that share an unlimited
+// dimension, keeping them in lockstep: every Write starts at the same record
+// for all variables.
+type RecordWriter struct {
+	dim    Dim
+	vars   []Var
+	dims   [][]Dim
+	recIdx []int
+}
+
+// NewRecordWriter returns a RecordWriter for the named variables of group.
+// The record dimension is the first unlimited dimension of the first
+// variable; all the other variables must use it too.
+func NewRecordWriter(group *Group, varNames ...string) (*RecordWriter, error) {
+	if group.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke NewRecordWriter on a Null group")
+	}
+	if len(varNames) == 0 {
+		return nil, fmt.Errorf("error: NewRecordWriter needs at least one variable")
+	}
+
+	w := &RecordWriter{}
+	for i, name := range varNames {
+		v, err := group.GetVar(name, Current)
+		if err != nil {
+			return nil, err
+		}
+		if v.IsNull() {
+			return nil, fmt.Errorf("error: no variable %q in NewRecordWriter", name)
+		}
+		dims, err := v.GetDims()
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			idx, err := recordDimIndex(dims)
+			if err != nil {
+				return nil, err
+			}
+			if idx < 0 {
+				return nil, fmt.Errorf("error: variable %q has no unlimited dimension", name)
+			}
+			w.dim = dims[idx]
+		}
+		idx := -1
+		for j, dim := range dims {
+			if dim.ID() == w.dim.ID() {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("error: variable %q does not use the record dimension of %q", name, varNames[0])
+		}
+		w.vars = append(w.vars, v)
+		w.dims = append(w.dims, dims)
+		w.recIdx = append(w.recIdx, idx)
+	}
+	return w, nil
+}
+
+// Dim returns the record dimension.
+func (w *RecordWriter) Dim() Dim {
+	return w.dim
+}
+
+// Records returns the current number of records.
+func (w *RecordWriter) Records() (int, error) {
+	return w.dim.GetSize()
+}
+
+// Write appends records to every variable and returns the new number of
+// records. It takes one data slice per variable, in the order given to
+// NewRecordWriter, and each must hold the same number of records. All data
+// is checked before anything is written; if the library then fails part way
+// through, the variables written so far keep their new records.
+func (w *RecordWriter) Write(data ...interface{}) (int, error) {
+	if len(data) != len(w.vars) {
+		return 0, fmt.Errorf("error: RecordWriter.Write got %d data slices for %d variables", len(data), len(w.vars))
+	}
+	first, err := w.dim.GetSize()
+	if err != nil {
+		return 0, err
+	}
+
+	starts := make([][]int, len(w.vars))
+	counts := make([][]int, len(w.vars))
+	nRecs := 0
+	for i := range w.vars {
+		starts[i], counts[i], err = recordSlab(w.dims[i], w.recIdx[i], first, data[i])
+		if err != nil {
+			return 0, err
+		}
+		n := counts[i][w.recIdx[i]]
+		if i == 0 {
+			nRecs = n
+		} else if n != nRecs {
+			return 0, fmt.Errorf("error: RecordWriter.Write got %d records for variable %d but %d for variable 0", n, i, nRecs)
+		}
+	}
+
+	for i, v := range w.vars {
+		if err := v.PutSlab(starts[i], counts[i], data[i]); err != nil {
+			return 0, err
+		}
+	}
+	return first + nRecs, nil
+}
+
+// recordDimIndex returns the position of the first unlimited dimension in
+// dims, -1 if there is none.
+func recordDimIndex(dims []Dim) (int, error) {
+	for i, dim := range dims {
+		isUnlimited, err := dim.IsUnlimited()
+		if err != nil {
+			return -1, err
+		}
+		if isUnlimited {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// recordSlab returns the hyperslab holding data as records along dims[recIdx],
+// starting at record first. The other dimensions are written in full, using
+// their current size; they cannot be unlimited, as the size of a record
+// would then be ambiguous.
+func recordSlab(dims []Dim, recIdx int, first int, data interface{}) (start, count []int, err error) {
+	n, err := dataLen(unwrapArray(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	start = make([]int, len(dims))
+	count = make([]int, len(dims))
+	recSize := 1
+	for i, dim := range dims {
+		if i == recIdx {
+			continue
+		}
+		isUnlimited, err := dim.IsUnlimited()
+		if err != nil {
+			return nil, nil, err
+		}
+		if isUnlimited {
+			name, _ := dim.Name()
+			return nil, nil, fmt.Errorf("error: cannot write records of a variable with a second unlimited dimension %q", name)
+		}
+		if count[i], err = dim.GetSize(); err != nil {
+			return nil, nil, err
+		}
+		recSize *= count[i]
+	}
+	if recSize == 0 || n%recSize != 0 {
+		return nil, nil, fmt.Errorf("error: data length %d is not a multiple of the record size %d", n, recSize)
+	}
+	start[recIdx] = first
+	count[recIdx] = n / recSize
+	return start, count, nil
+}
@@ -0,0 +1,664 @@
+package netcdf4
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reduction is a statistic computed along a dimension.
+type Reduction int
+
+// Known reductions
+const (
+	ReduceSum  Reduction = iota // Sum of the values.
+	ReduceMean                  // Mean of the values.
+	ReduceMin                   // Minimum of the values.
+	ReduceMax                   // Maximum of the values.
+	ReduceStd                   // Population standard deviation of the values.
+)
+
+var reductionNames = [...]string{"sum", "mean", "minimum", "maximum", "standard_deviation"}
+
+// String returns the name of the reduction in CF cell_methods, e.g. "mean".
+func (r Reduction) String() string {
+	if r < 0 || int(r) >= len(reductionNames) {
+		return "Reduction(" + strconv.FormatInt(int64(r), 10) + ")"
+	}
+	return reductionNames[r]
+}
+
+// Period is the length of the time intervals of a resampling.
+type Period int
+
+// Known periods
+const (
+	Hourly  Period = iota // Hours, or the hour of the day in a climatology.
+	Daily                 // Days, or the day of the year in a climatology.
+	Monthly               // Months, or the month of the year in a climatology.
+	Yearly                // Years.
+)
+
+var periodNames = [...]string{"Hourly", "Daily", "Monthly", "Yearly"}
+
+// String conforms to fmt.Stringer interface
+func (p Period) String() string {
+	if p < 0 || int(p) >= len(periodNames) {
+		return "Period(" + strconv.FormatInt(int64(p), 10) + ")"
+	}
+	return periodNames[p]
+}
+
+// packingAttrs are the attributes that describe stored values; they do not
+// apply to unpacked or reduced values.
+var packingAttrs = []string{"_FillValue", "missing_value", "scale_factor", "add_offset",
+	"valid_min", "valid_max", "valid_range", "_Unsigned"}
+
+// grouping describes how a reduction maps the indices along its dimension to
+// the output: groups holds the output index of each index, nil when the
+// dimension is reduced away, and coord, bounds and coordAttrs describe the
+// output coordinate.
+type grouping struct {
+	groups     []int
+	coord      []float64
+	bounds     [][2]float64
+	coordAttrs map[string]interface{}
+	// interval is the length of the intervals of a resampling, e.g. "1 day",
+	// and over the unit of time a climatology is taken over, e.g. "years"
+	interval string
+	over     string
+}
+
+// Reduce computes r of the unpacked values of the variable along dimension
+// dim, which is dropped from the result. Missing values are left out; cells
+// with none are NaN. The variable is read in slabs as by Var.Stats, whose
+// StatsBufferSize and StatsReadOptions options apply.
+//
+// The result keeps the coordinates and attributes of the variable, less its
+// packing attributes, and records the reduction in its cell_methods
+// attribute, so that WriteDataArray can write it back.
+func (v Var) Reduce(dim string, r Reduction, opts ...StatsOption) (*DataArray[float64], error) {
+	if v.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke Reduce on a Null variable")
+	}
+	return v.reduce(dim, r, opts, func(Var) (*grouping, error) {
+		return &grouping{}, nil
+	})
+}
+
+// Resample computes r of the unpacked values of the variable over the
+// intervals of period p along its time dimension dim, e.g. daily means of
+// hourly data. The time coordinate variable of dim is decoded with its units
+// and calendar; the result has one value per interval that holds data, with
+// the start of the interval as its time coordinate, in the same units, and
+// the interval as its bounds. The interval is recorded in cell_methods, as
+// in "time: mean (interval: 1 day)". Options and attributes are as for
+// Var.Reduce.
+func (v Var) Resample(dim string, p Period, r Reduction, opts ...StatsOption) (*DataArray[float64], error) {
+	if v.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke Resample on a Null variable")
+	}
+	return v.reduce(dim, r, opts, func(cv Var) (*grouping, error) {
+		coord, attrs, err := readCoord(dim, cv)
+		if err != nil {
+			return nil, err
+		}
+		return timeGrouping(dim, coord, attrs, p, false)
+	})
+}
+
+// Climatology computes r of the unpacked values of the variable for each
+// hour of the day, day of the year or month of the year over all the times
+// of its time dimension dim, for p Hourly, Daily and Monthly respectively;
+// Monthly gives a monthly climatology. The result follows the CF
+// conventions for climatologies: dim has one value per hour, day or month
+// present in the data, whose time coordinate is its first occurrence, and
+// whose bounds, named by the coordinate's climatology attribute, run from
+// that to the end of its last occurrence; cell_methods reads e.g.
+// "time: mean within years time: mean over years". Options and
+// attributes are as for Var.Reduce.
+func (v Var) Climatology(dim string, p Period, r Reduction, opts ...StatsOption) (*DataArray[float64], error) {
+	if v.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke Climatology on a Null variable")
+	}
+	return v.reduce(dim, r, opts, func(cv Var) (*grouping, error) {
+		coord, attrs, err := readCoord(dim, cv)
+		if err != nil {
+			return nil, err
+		}
+		return timeGrouping(dim, coord, attrs, p, true)
+	})
+}
+
+// readCoord reads the coordinate variable of dimension dim and its
+// attributes; cv is a Null variable if the dimension has none.
+func readCoord(dim string, cv Var) ([]float64, map[string]interface{}, error) {
+	if cv.IsNull() {
+		return nil, nil, fmt.Errorf("error: dimension %q has no coordinate variable", dim)
+	}
+	values, err := cv.ReadUnpacked()
+	if err != nil {
+		return nil, nil, err
+	}
+	attrs, err := varAttrs(cv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return values.Data(), attrs, nil
+}
+
+// reduce computes r of v along dim, grouped as group returns from the
+// coordinate variable of dim, a Null variable if there is none.
+func (v Var) reduce(dim string, r Reduction, opts []StatsOption, group func(cv Var) (*grouping, error)) (*DataArray[float64], error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	cfg := newStatsConfig(opts)
+	dims, err := v.GetDims()
+	if err != nil {
+		return nil, err
+	}
+	names, shape, err := v.dimNames()
+	if err != nil {
+		return nil, err
+	}
+	axis := slices.Index(names, dim)
+	if axis < 0 {
+		return nil, fmt.Errorf("error: no dimension %q", dim)
+	}
+	cv, _, err := coordinateVar(dims[axis])
+	if err != nil {
+		return nil, err
+	}
+	g, err := group(cv)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := newGroupAccumulator(axisGroups(shape, axis, g.groups))
+	if err := v.accumulate(shape, cfg, acc, nil); err != nil {
+		return nil, err
+	}
+	values, err := acc.result(r)
+	if err != nil {
+		return nil, err
+	}
+	da, err := newDataArray(v, values)
+	if err != nil {
+		return nil, err
+	}
+	da.reduced(axis, g, r)
+	rc := &readConfig{}
+	for _, opt := range cfg.readOpts {
+		opt(rc)
+	}
+	if rc.units != "" {
+		da.Attrs["units"] = rc.units
+	}
+	return da, nil
+}
+
+// Reduce computes r of the values along dimension dim, which is dropped from
+// the result. NaN values are left out; cells with none are NaN. The values
+// are taken as they are: read packed variables, or those with fill values,
+// with ReadDataArrayUnpacked. Coordinates and attributes are as for
+// Var.Reduce.
+func (d *DataArray[T]) Reduce(dim string, r Reduction) (*DataArray[float64], error) {
+	axis, err := d.DimIndex(dim)
+	if err != nil {
+		return nil, err
+	}
+	return d.reduce(axis, r, &grouping{})
+}
+
+// Resample computes r of the values over the intervals of period p along the
+// time dimension dim, decoding its coordinate with the units and calendar
+// held in CoordAttrs, as Var.Resample does.
+func (d *DataArray[T]) Resample(dim string, p Period, r Reduction) (*DataArray[float64], error) {
+	axis, err := d.DimIndex(dim)
+	if err != nil {
+		return nil, err
+	}
+	coord, err := d.coord(dim)
+	if err != nil {
+		return nil, err
+	}
+	g, err := timeGrouping(dim, coord, d.CoordAttrs[dim], p, false)
+	if err != nil {
+		return nil, err
+	}
+	return d.reduce(axis, r, g)
+}
+
+// Climatology computes r of the values for each hour of the day, day of the
+// year or month of the year along the time dimension dim, as
+// Var.Climatology does.
+func (d *DataArray[T]) Climatology(dim string, p Period, r Reduction) (*DataArray[float64], error) {
+	axis, err := d.DimIndex(dim)
+	if err != nil {
+		return nil, err
+	}
+	coord, err := d.coord(dim)
+	if err != nil {
+		return nil, err
+	}
+	g, err := timeGrouping(dim, coord, d.CoordAttrs[dim], p, true)
+	if err != nil {
+		return nil, err
+	}
+	return d.reduce(axis, r, g)
+}
+
+func (d *DataArray[T]) reduce(axis int, r Reduction, g *grouping) (*DataArray[float64], error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	shape := d.Values.Shape()
+	acc := newGroupAccumulator(axisGroups(shape, axis, g.groups))
+	data := make([]float64, d.Values.Len())
+	for i, x := range d.Values.data {
+		data[i] = float64(x)
+	}
+	acc.addSlab(make([]int, len(shape)), shape, data)
+	values, err := acc.result(r)
+	if err != nil {
+		return nil, err
+	}
+	out := &DataArray[float64]{
+		Name:        d.Name,
+		Dims:        append([]string{}, d.Dims...),
+		Values:      values,
+		Coords:      maps.Clone(d.Coords),
+		CoordAttrs:  maps.Clone(d.CoordAttrs),
+		CoordBounds: maps.Clone(d.CoordBounds),
+		Attrs:       maps.Clone(d.Attrs),
+	}
+	out.reduced(axis, g, r)
+	return out, nil
+}
+
+// axisGroups returns the groups of newGroupAccumulator that keep every
+// dimension of shape but axis, which is grouped by groups.
+func axisGroups(shape []int, axis int, groups []int) [][]int {
+	all := make([][]int, len(shape))
+	for i, size := range shape {
+		if i == axis {
+			all[i] = groups
+			continue
+		}
+		all[i] = make([]int, size)
+		for j := range all[i] {
+			all[i][j] = j
+		}
+	}
+	return all
+}
+
+// check returns an error if r is not one of the known reductions.
+func (r Reduction) check() error {
+	if r < ReduceSum || r > ReduceStd {
+		return fmt.Errorf("error: unknown reduction %v", r)
+	}
+	return nil
+}
+
+// result returns the values of reduction r.
+func (a *accumulator) result(r Reduction) (*Array[float64], error) {
+	switch r {
+	case ReduceSum:
+		return a.total(), nil
+	case ReduceMean:
+		return a.mean, nil
+	case ReduceMin:
+		return a.min, nil
+	case ReduceMax:
+		return a.max, nil
+	case ReduceStd:
+		return a.std(), nil
+	default:
+		return nil, fmt.Errorf("error: unknown reduction %v", r)
+	}
+}
+
+// reduced relabels d, whose values have been reduced by r along axis as g
+// says: the dimension is dropped or takes the coordinate of g, the packing
+// attributes are dropped and the reduction is appended to the cell_methods
+// attribute.
+func (d *DataArray[T]) reduced(axis int, g *grouping, r Reduction) {
+	if d.Coords == nil {
+		d.Coords = map[string][]float64{}
+	}
+	if d.CoordAttrs == nil {
+		d.CoordAttrs = map[string]map[string]interface{}{}
+	}
+	if d.CoordBounds == nil {
+		d.CoordBounds = map[string][][2]float64{}
+	}
+	if d.Attrs == nil {
+		d.Attrs = map[string]interface{}{}
+	}
+	name := d.Dims[axis]
+	delete(d.Coords, name)
+	delete(d.CoordAttrs, name)
+	delete(d.CoordBounds, name)
+	if g.groups == nil {
+		d.Dims = append(d.Dims[:axis], d.Dims[axis+1:]...)
+	} else {
+		d.Coords[name] = g.coord
+		d.CoordAttrs[name] = g.coordAttrs
+		d.CoordBounds[name] = g.bounds
+	}
+	for _, att := range packingAttrs {
+		delete(d.Attrs, att)
+	}
+
+	method := name + ": " + r.String()
+	switch {
+	case g.over != "":
+		method = fmt.Sprintf("%s: %s within %s %s: %s over %s", name, r, g.over, name, r, g.over)
+	case g.interval != "":
+		method += " (interval: " + g.interval + ")"
+	}
+	if prev, ok := d.Attrs["cell_methods"].(string); ok && strings.TrimSpace(prev) != "" {
+		method = strings.TrimSpace(prev) + " " + method
+	}
+	d.Attrs["cell_methods"] = method
+}
+
+// timeGrouping groups the times of coord, decoded with the units and
+// calendar attributes in attrs, by the intervals of p, or for a climatology
+// by their position within the day or year.
+func timeGrouping(dim string, coord []float64, attrs map[string]interface{}, p Period, climatology bool) (*grouping, error) {
+	if p < Hourly || p > Yearly || climatology && p == Yearly {
+		return nil, fmt.Errorf("error: invalid period %v", p)
+	}
+	units, c, err := coordTimeUnits(dim, attrs)
+	if err != nil {
+		return nil, err
+	}
+	times, err := DecodeTimes(coord, units, c)
+	if err != nil {
+		return nil, err
+	}
+
+	// the key of a time is the start of its interval or, for a climatology,
+	// its hour, day or month
+	starts := make([][4]int, len(times))
+	keys := make([][4]int, len(times))
+	for i, t := range times {
+		if t.IsZero() {
+			return nil, fmt.Errorf("error: missing time at index %d of dimension %q", i, dim)
+		}
+		switch p {
+		case Hourly:
+			starts[i] = [4]int{t.Year, t.Month, t.Day, t.Hour}
+		case Daily:
+			starts[i] = [4]int{t.Year, t.Month, t.Day}
+		case Monthly:
+			starts[i] = [4]int{t.Year, t.Month, 1}
+		default:
+			starts[i] = [4]int{t.Year, 1, 1}
+		}
+		switch {
+		case !climatology:
+			keys[i] = starts[i]
+		case p == Hourly:
+			keys[i] = [4]int{t.Hour}
+		case p == Daily:
+			keys[i] = [4]int{int(c.dayNumber(t.Year, t.Month, t.Day)-c.dayNumber(t.Year, 1, 1)) + 1}
+		default:
+			keys[i] = [4]int{t.Month}
+		}
+	}
+	compare := func(a, b [4]int) int { return slices.Compare(a[:], b[:]) }
+	unique := slices.Clone(keys)
+	sort.Slice(unique, func(i, j int) bool { return compare(unique[i], unique[j]) < 0 })
+	unique = slices.Compact(unique)
+
+	// the first and last interval of each group
+	g := &grouping{groups: make([]int, len(keys))}
+	first, last := make([][4]int, len(unique)), make([][4]int, len(unique))
+	seen := make([]bool, len(unique))
+	for i, k := range keys {
+		j, _ := slices.BinarySearchFunc(unique, k, compare)
+		g.groups[i] = j
+		if !seen[j] || compare(starts[i], first[j]) < 0 {
+			first[j] = starts[i]
+		}
+		if !seen[j] || compare(starts[i], last[j]) > 0 {
+			last[j] = starts[i]
+		}
+		seen[j] = true
+	}
+	lo, hi := make([]CFTime, len(unique)), make([]CFTime, len(unique))
+	for j := range unique {
+		lo[j] = CFTime{Year: first[j][0], Month: first[j][1], Day: first[j][2], Hour: first[j][3], Calendar: c}
+		end := CFTime{Year: last[j][0], Month: last[j][1], Day: last[j][2], Hour: last[j][3], Calendar: c}
+		switch p {
+		case Hourly:
+			end = end.Add(time.Hour)
+		case Daily:
+			end = end.Add(24 * time.Hour)
+		case Monthly:
+			end.Year, end.Month = end.Year+end.Month/12, end.Month%12+1
+		default:
+			end.Year++
+		}
+		hi[j] = end
+	}
+	if g.coord, err = EncodeTimes(lo, units, c); err != nil {
+		return nil, err
+	}
+	upper, err := EncodeTimes(hi, units, c)
+	if err != nil {
+		return nil, err
+	}
+	g.bounds = make([][2]float64, len(unique))
+	for j := range g.bounds {
+		g.bounds[j] = [2]float64{g.coord[j], upper[j]}
+	}
+
+	// the bounds variable of the input no longer matches
+	g.coordAttrs = maps.Clone(attrs)
+	delete(g.coordAttrs, "bounds")
+	delete(g.coordAttrs, "climatology")
+	if climatology {
+		g.coordAttrs["climatology"] = dim + "_climatology_bnds"
+		g.over = "years"
+		if p == Hourly {
+			g.over = "days"
+		}
+	} else {
+		g.coordAttrs["bounds"] = dim + "_bnds"
+		g.interval = [...]string{"1 hour", "1 day", "1 month", "1 year"}[p]
+	}
+	return g, nil
+}
+
+// ReadDataArrayUnpacked reads the whole variable as ReadDataArray does, with
+// its values unpacked as by Var.ReadUnpacked: missing values are NaN and the
+// packing attributes are left out.
+func ReadDataArrayUnpacked(v Var, opts ...ReadOption) (*DataArray[float64], error) {
+	values, err := v.ReadUnpacked(opts...)
+	if err != nil {
+		return nil, err
+	}
+	da, err := newDataArray(v, values)
+	if err != nil {
+		return nil, err
+	}
+	for _, att := range packingAttrs {
+		delete(da.Attrs, att)
+	}
+	cfg := &readConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.units != "" {
+		da.Attrs["units"] = cfg.units
+	}
+	return da, nil
+}
+
+// WriteDataArray defines a variable of d's name, dimensions and attributes
+// in g and writes d's values into it. Dimensions not found in g or its
+// parents are added with the sizes of d; those found must have the same
+// size. Coordinates of d whose dimension has no coordinate variable yet are
+// written as double variables with their CoordAttrs, together with their
+// CoordBounds as the variable named by their bounds or climatology
+// attribute, along a dimension bnds of size 2; a bounds or climatology
+// attribute without CoordBounds is left out. A _FillValue attribute is
+// converted to the type of the values.
+func WriteDataArray[T Numeric](g *Group, d *DataArray[T]) (Var, error) {
+	if g.IsNull() {
+		return NewVarNull(), fmt.Errorf("error: attempt to invoke WriteDataArray on a Null group")
+	}
+	shape := d.Values.Shape()
+	if len(d.Dims) != len(shape) {
+		return NewVarNull(), fmt.Errorf("error: %s has %d dimension names for values of rank %d", d.Name, len(d.Dims), len(shape))
+	}
+
+	dims := make([]Dim, len(d.Dims))
+	for i, name := range d.Dims {
+		dim, err := g.GetDim(name, ParentsAndCurrent)
+		if err != nil {
+			return NewVarNull(), err
+		}
+		if dim.IsNull() {
+			if dim, err = g.AddDim(name, uint(shape[i])); err != nil {
+				return NewVarNull(), err
+			}
+		} else if size, err := dim.GetSize(); err != nil {
+			return NewVarNull(), err
+		} else if size != shape[i] {
+			return NewVarNull(), fmt.Errorf("error: dimension %q has size %d, not %d", name, size, shape[i])
+		}
+		dims[i] = dim
+
+		coord, ok := d.Coords[name]
+		if !ok {
+			continue
+		}
+		if _, exists, err := coordinateVar(dim); err != nil {
+			return NewVarNull(), err
+		} else if exists {
+			continue
+		}
+		if len(coord) != shape[i] {
+			return NewVarNull(), fmt.Errorf("error: coordinate %q has %d values for a dimension of size %d", name, len(coord), shape[i])
+		}
+		attrs, bounds := d.CoordAttrs[name], d.CoordBounds[name]
+		boundsName := ""
+		for _, att := range []string{"bounds", "climatology"} {
+			if text, ok := attrs[att].(string); ok && text != "" {
+				boundsName = text
+			}
+		}
+		if boundsName != "" && len(bounds) != shape[i] {
+			// leave out the attribute rather than name a missing variable
+			attrs = maps.Clone(attrs)
+			delete(attrs, "bounds")
+			delete(attrs, "climatology")
+			boundsName = ""
+		}
+		cv, err := g.AddVar(name, Double, []Dim{dim})
+		if err != nil {
+			return NewVarNull(), err
+		}
+		if err := putAttrs[float64](cv, attrs); err != nil {
+			return NewVarNull(), err
+		}
+		if err := cv.PutValAll(coord); err != nil {
+			return NewVarNull(), err
+		}
+		if boundsName != "" {
+			if err := writeBounds(g, boundsName, dim, bounds); err != nil {
+				return NewVarNull(), err
+			}
+		}
+	}
+
+	t, err := sliceType(d.Values.Data())
+	if err != nil {
+		return NewVarNull(), err
+	}
+	v, err := g.AddVar(d.Name, t, dims)
+	if err != nil {
+		return NewVarNull(), err
+	}
+	if err := putAttrs[T](v, d.Attrs); err != nil {
+		return NewVarNull(), err
+	}
+	if err := v.PutValAll(d.Values.Data()); err != nil {
+		return NewVarNull(), err
+	}
+	return v, nil
+}
+
+// writeBounds writes the bounds of the coordinate of dim as the variable
+// name, along dim and a dimension bnds of size 2.
+func writeBounds(g *Group, name string, dim Dim, bounds [][2]float64) error {
+	bnds, err := g.GetDim("bnds", ParentsAndCurrent)
+	if err != nil {
+		return err
+	}
+	if bnds.IsNull() {
+		if bnds, err = g.AddDim("bnds", 2); err != nil {
+			return err
+		}
+	} else if size, err := bnds.GetSize(); err != nil {
+		return err
+	} else if size != 2 {
+		return fmt.Errorf("error: dimension \"bnds\" has size %d, not 2", size)
+	}
+	bv, err := g.AddVar(name, Double, []Dim{dim, bnds})
+	if err != nil {
+		return err
+	}
+	data := make([]float64, 0, 2*len(bounds))
+	for _, b := range bounds {
+		data = append(data, b[0], b[1])
+	}
+	return bv.PutValAll(data)
+}
+
+// putAttrs writes attrs to v in name order, converting a numeric _FillValue
+// to T, the type of v.
+func putAttrs[T Numeric](v Var, attrs map[string]interface{}) error {
+	for _, name := range slices.Sorted(maps.Keys(attrs)) {
+		value := attrs[name]
+		if name == "_FillValue" {
+			rv := reflect.ValueOf(value)
+			if !rv.IsValid() {
+				return fmt.Errorf("error: no value for attribute %q", name)
+			}
+			if rv.Kind() != reflect.Slice {
+				rv = reflect.Append(reflect.MakeSlice(reflect.SliceOf(rv.Type()), 0, 1), rv)
+			}
+			fill := make([]T, rv.Len())
+			for i := range fill {
+				x := rv.Index(i)
+				switch {
+				case x.CanFloat():
+					fill[i] = T(x.Float())
+				case x.CanInt():
+					fill[i] = T(x.Int())
+				case x.CanUint():
+					fill[i] = T(x.Uint())
+				default:
+					return fmt.Errorf("error: _FillValue of type %T is not numeric", value)
+				}
+			}
+			value = fill
+		}
+		if _, err := v.PutAtt(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package netcdf4
+
+// forEachSlab calls fn with the start and count of hyperslabs that cover an
+// array of the given shape, in row-major order of the slabs. Each slab holds
+// at most maxElems values, splitting the fastest varying dimensions as little
+// as possible. When chunks holds the chunk sizes of the variable and a chunk
+// fits in maxElems, the slabs are made of whole chunks instead, so that each
+// chunk is read once. fn must not keep start or count, they are reused.
+func forEachSlab(shape, chunks []int, maxElems int, fn func(start, count []int) error) error {
+	n := len(shape)
+	for _, size := range shape {
+		if size == 0 {
+			return nil
+		}
+	}
+	if maxElems < 1 {
+		maxElems = 1
+	}
+
+	// the dimensions from split+1 on are read whole
+	split, inner := n-1, 1
+	for split >= 0 && inner*shape[split] <= maxElems {
+		inner *= shape[split]
+		split--
+	}
+	start := make([]int, n)
+	count := make([]int, n)
+	copy(count, shape)
+	if split < 0 {
+		return fn(start, count)
+	}
+
+	if ext := chunkTile(shape, chunks, maxElems); ext != nil {
+		for {
+			for d := range count {
+				count[d] = min(ext[d], shape[d]-start[d])
+			}
+			if err := fn(start, count); err != nil {
+				return err
+			}
+			d := n - 1
+			for ; d >= 0; d-- {
+				start[d] += ext[d]
+				if start[d] < shape[d] {
+					break
+				}
+				start[d] = 0
+			}
+			if d < 0 {
+				return nil
+			}
+		}
+	}
+
+	// along split read step indices at a time, along the slower dimensions one
+	step := maxElems / inner
+	if len(chunks) == n && chunks[split] > 0 && step > chunks[split] {
+		step -= step % chunks[split]
+	}
+	for i := 0; i < split; i++ {
+		count[i] = 1
+	}
+	for {
+		count[split] = min(step, shape[split]-start[split])
+		if err := fn(start, count); err != nil {
+			return err
+		}
+		start[split] += step
+		for i := split; start[i] >= shape[i]; {
+			start[i] = 0
+			i--
+			if i < 0 {
+				return nil
+			}
+			start[i]++
+		}
+	}
+}
+
+// chunkTile returns the extents of slabs made of whole chunks, grown from
+// the fastest varying dimension as far as maxElems allows, or nil if chunks
+// are not given or a single chunk holds more than maxElems values.
+func chunkTile(shape, chunks []int, maxElems int) []int {
+	if len(chunks) != len(shape) {
+		return nil
+	}
+	ext := make([]int, len(shape))
+	size := 1
+	for d, c := range chunks {
+		if c <= 0 {
+			return nil
+		}
+		ext[d] = min(c, shape[d])
+		size *= ext[d]
+	}
+	if size > maxElems {
+		return nil
+	}
+	for d := len(shape) - 1; d >= 0; d-- {
+		others := size / ext[d]
+		ext[d] = max(ext[d], min(shape[d], maxElems/others/chunks[d]*chunks[d]))
+		size = others * ext[d]
+		if ext[d] < shape[d] {
+			break
+		}
+	}
+	return ext
+}
@@ -0,0 +1,357 @@
+package netcdf4
+
+import (
+	"fmt"
+	"math"
+)
+
+const defaultStatsBufferSize = 16 << 20
+
+// StatsOption configures Var.Stats.
+type StatsOption func(*statsConfig)
+
+type statsConfig struct {
+	over       []string // dimensions reduced over, all if empty
+	bins       int
+	histMin    float64
+	histMax    float64
+	bufferSize int
+	readOpts   []ReadOption
+}
+
+// StatsOver reduces over the named dimensions only, keeping the others:
+// StatsOver("time") gives the statistics of each grid cell over time. By
+// default the statistics are over the whole variable.
+func StatsOver(dims ...string) StatsOption {
+	return func(c *statsConfig) { c.over = dims }
+}
+
+// StatsHistogram adds a histogram of all the valid values with bins bins of
+// equal width between min and max.
+func StatsHistogram(bins int, min, max float64) StatsOption {
+	return func(c *statsConfig) { c.bins, c.histMin, c.histMax = bins, min, max }
+}
+
+// StatsBufferSize bounds the memory, in bytes, used to read the variable;
+// the default is 16 MiB. The statistics themselves take memory in
+// proportion to the number of values kept, the product of the sizes of the
+// dimensions not reduced over.
+func StatsBufferSize(size int) StatsOption {
+	return func(c *statsConfig) { c.bufferSize = size }
+}
+
+// StatsReadOptions passes options, such as ReadInUnits, to the reads.
+func StatsReadOptions(opts ...ReadOption) StatsOption {
+	return func(c *statsConfig) { c.readOpts = opts }
+}
+
+// Stats holds the statistics computed by Var.Stats. The arrays have the
+// shape of the dimensions that were not reduced over, named by Dims; when
+// the statistics are over the whole variable they have rank 0 and a single
+// value.
+type Stats struct {
+	Dims []string
+	// Count is the number of valid values, those that are not fill,
+	// missing or out of the valid range.
+	Count *Array[int64]
+	// Min, Max, Sum, Mean and Std are the minimum, maximum, sum, mean and
+	// population standard deviation of the valid values, NaN where there are
+	// none.
+	Min, Max, Sum, Mean, Std *Array[float64]
+	// Histogram is set if StatsHistogram was given.
+	Histogram *Histogram
+}
+
+// Histogram counts values in bins of equal width.
+type Histogram struct {
+	Min, Max float64
+	// Counts holds the number of values in each bin; the last bin includes
+	// Max.
+	Counts []int64
+	// Under and Over count the values below Min and above Max.
+	Under, Over int64
+}
+
+// add counts x, which is not NaN.
+func (h *Histogram) add(x float64) {
+	switch {
+	case x < h.Min:
+		h.Under++
+	case x > h.Max:
+		h.Over++
+	default:
+		i := int(float64(len(h.Counts)) * (x - h.Min) / (h.Max - h.Min))
+		h.Counts[min(i, len(h.Counts)-1)]++
+	}
+}
+
+// Stats computes statistics of the unpacked values of the variable in a
+// single pass, reading it in slabs aligned to its chunks so that memory
+// stays bounded whatever its size. Fill, missing and out of range values
+// are left out, see Packing.
+func (v Var) Stats(opts ...StatsOption) (*Stats, error) {
+	if v.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke Stats on a Null variable")
+	}
+	cfg := newStatsConfig(opts)
+	if cfg.bins < 0 || cfg.bins > 0 && !(cfg.histMax > cfg.histMin) {
+		return nil, fmt.Errorf("error: invalid histogram of %d bins over [%v, %v]", cfg.bins, cfg.histMin, cfg.histMax)
+	}
+
+	names, shape, err := v.dimNames()
+	if err != nil {
+		return nil, err
+	}
+	reduced, err := reducedAxes(names, cfg.over)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := newAccumulator(shape, reduced)
+	s := &Stats{}
+	for i, name := range names {
+		if !reduced[i] {
+			s.Dims = append(s.Dims, name)
+		}
+	}
+	if cfg.bins > 0 {
+		s.Histogram = &Histogram{Min: cfg.histMin, Max: cfg.histMax, Counts: make([]int64, cfg.bins)}
+	}
+	if err := v.accumulate(shape, cfg, acc, s.Histogram); err != nil {
+		return nil, err
+	}
+
+	s.Count, s.Min, s.Max, s.Mean = acc.count, acc.min, acc.max, acc.mean
+	s.Sum, s.Std = acc.total(), acc.std()
+	return s, nil
+}
+
+func newStatsConfig(opts []StatsOption) *statsConfig {
+	cfg := &statsConfig{bufferSize: defaultStatsBufferSize}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// dimNames returns the names of the dimensions of v and its shape.
+func (v Var) dimNames() ([]string, []int, error) {
+	dims, err := v.GetDims()
+	if err != nil {
+		return nil, nil, err
+	}
+	shape, err := v.Shape()
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, len(dims))
+	for i, dim := range dims {
+		if names[i], err = dim.Name(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return names, shape, nil
+}
+
+// accumulate reads the unpacked values of v, of the given shape, in slabs
+// aligned to its chunks and adds them to acc and, if it is not nil, to h.
+func (v Var) accumulate(shape []int, cfg *statsConfig, acc *accumulator, h *Histogram) error {
+	// chunk sizes, when the format has them
+	chunks := []int(nil)
+	if chunked, sizes, err := ncInqVarChunking(v.groupId, v.myId, len(shape)); err == nil && chunked {
+		chunks = sizes
+	}
+	// a value takes at most 16 bytes, stored and as a float64
+	return forEachSlab(shape, chunks, cfg.bufferSize/16, func(start, count []int) error {
+		values, err := v.ReadUnpackedSlab(start, count, cfg.readOpts...)
+		if err != nil {
+			return err
+		}
+		acc.addSlab(start, count, values.data)
+		if h != nil {
+			for _, x := range values.data {
+				if !math.IsNaN(x) {
+					h.add(x)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// reducedAxes marks the dimensions named in over, all of them if over is
+// empty.
+func reducedAxes(names, over []string) ([]bool, error) {
+	reduced := make([]bool, len(names))
+	if len(over) == 0 {
+		for i := range reduced {
+			reduced[i] = true
+		}
+		return reduced, nil
+	}
+	for _, name := range over {
+		found := false
+		for i, n := range names {
+			if n == name {
+				reduced[i], found = true, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("error: no dimension %q", name)
+		}
+	}
+	return reduced, nil
+}
+
+// accumulator gathers the count, minimum, maximum, sum, mean and sum of
+// squared deviations (Welford's method) of the values of an array, reduced
+// over some of its dimensions or over groups of indices along them.
+type accumulator struct {
+	// offsets holds, for each dimension, the contribution of each index to
+	// the output offset; it is nil for the dimensions reduced over.
+	offsets [][]int
+	count   *Array[int64]
+	min     *Array[float64]
+	max     *Array[float64]
+	mean    *Array[float64]
+	sum     []float64
+	m2      []float64
+}
+
+func newAccumulator(shape []int, reduced []bool) *accumulator {
+	groups := make([][]int, len(shape))
+	for i, size := range shape {
+		if !reduced[i] {
+			groups[i] = make([]int, size)
+			for j := range groups[i] {
+				groups[i][j] = j
+			}
+		}
+	}
+	return newGroupAccumulator(groups)
+}
+
+// newGroupAccumulator returns an accumulator where groups[d][i] is the output
+// index of index i along dimension d, nil for the dimensions reduced over.
+// Output indices run from 0 to the largest one.
+func newGroupAccumulator(groups [][]int) *accumulator {
+	a := &accumulator{offsets: make([][]int, len(groups))}
+	outShape := []int{}
+	for _, g := range groups {
+		if g == nil {
+			continue
+		}
+		n := 0
+		for _, k := range g {
+			n = max(n, k+1)
+		}
+		outShape = append(outShape, n)
+	}
+	stride := 1
+	for i, k := len(groups)-1, len(outShape)-1; i >= 0; i-- {
+		if groups[i] == nil {
+			continue
+		}
+		a.offsets[i] = make([]int, len(groups[i]))
+		for j, g := range groups[i] {
+			a.offsets[i][j] = g * stride
+		}
+		stride *= outShape[k]
+		k--
+	}
+	a.count = MakeArray[int64](outShape...)
+	a.min = MakeArray[float64](outShape...)
+	a.max = MakeArray[float64](outShape...)
+	a.mean = MakeArray[float64](outShape...)
+	a.sum = make([]float64, a.mean.Len())
+	a.m2 = make([]float64, a.mean.Len())
+	for i := range a.min.data {
+		a.min.data[i], a.max.data[i], a.mean.data[i] = math.NaN(), math.NaN(), math.NaN()
+	}
+	return a
+}
+
+// offset returns the contribution of index i along dimension d to the
+// output offset.
+func (a *accumulator) offset(d, i int) int {
+	if a.offsets[d] == nil {
+		return 0
+	}
+	return a.offsets[d][i]
+}
+
+// addSlab adds the values of the hyperslab at start, count; NaN values are
+// left out.
+func (a *accumulator) addSlab(start, count []int, values []float64) {
+	if len(values) == 0 {
+		return
+	}
+	idx := make([]int, len(count))
+	out := 0
+	for d, s := range start {
+		out += a.offset(d, s)
+	}
+	for k := 0; ; k++ {
+		a.add(out, values[k])
+		// advance idx and out together
+		d := len(idx) - 1
+		for ; d >= 0; d-- {
+			prev := a.offset(d, start[d]+idx[d])
+			idx[d]++
+			if idx[d] < count[d] {
+				out += a.offset(d, start[d]+idx[d]) - prev
+				break
+			}
+			idx[d] = 0
+			out += a.offset(d, start[d]) - prev
+		}
+		if d < 0 {
+			return
+		}
+	}
+}
+
+// add adds x to output cell i.
+func (a *accumulator) add(i int, x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	n := a.count.data[i] + 1
+	a.count.data[i] = n
+	a.sum[i] += x
+	if n == 1 {
+		a.min.data[i], a.max.data[i], a.mean.data[i] = x, x, x
+		return
+	}
+	a.min.data[i] = math.Min(a.min.data[i], x)
+	a.max.data[i] = math.Max(a.max.data[i], x)
+	delta := x - a.mean.data[i]
+	a.mean.data[i] += delta / float64(n)
+	a.m2[i] += delta * (x - a.mean.data[i])
+}
+
+// total returns the sums, NaN where there are no values.
+func (a *accumulator) total() *Array[float64] {
+	sum := MakeArray[float64](a.mean.shape...)
+	for i, n := range a.count.data {
+		if n == 0 {
+			sum.data[i] = math.NaN()
+		} else {
+			sum.data[i] = a.sum[i]
+		}
+	}
+	return sum
+}
+
+// std returns the population standard deviations.
+func (a *accumulator) std() *Array[float64] {
+	std := MakeArray[float64](a.mean.shape...)
+	for i, n := range a.count.data {
+		if n == 0 {
+			std.data[i] = math.NaN()
+		} else {
+			std.data[i] = math.Sqrt(a.m2[i] / float64(n))
+		}
+	}
+	return std
+}
@@ -0,0 +1,397 @@
+package netcdf4
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// GeoSubset is the part of a variable inside a latitude-longitude bounding
+// box, as returned by Var.SubsetBBox. The values are unpacked, with NaN for
+// missing values. For a rectilinear grid the latitude and longitude of the
+// subset are also the Coords of their dimensions.
+type GeoSubset struct {
+	*DataArray[float64]
+	// Lat and Lon are the coordinates of the subset: 1-D along their
+	// dimension for rectilinear grids, 2-D (y, x) for curvilinear ones.
+	// Longitudes are shifted by multiples of 360 into the range of the
+	// bounding box, so they do not jump where the subset crosses the edge
+	// of the grid.
+	Lat, Lon *Array[float64]
+}
+
+// SubsetBBox reads the part of the variable within the bounding box
+// [minLon, maxLon] x [minLat, maxLat], in degrees. The latitude and
+// longitude are found among the variable's Coordinates by their
+// standard_name or units, and may be 1-D coordinate variables (regular and
+// rectilinear grids) or 2-D auxiliary coordinates (curvilinear grids); the
+// other dimensions are read whole.
+//
+// Longitudes match whatever their convention, 0..360 or -180..180, and a box
+// with minLon > maxLon crosses the antimeridian. When the subset wraps
+// around the edge of a global grid it is read in two parts and joined, so
+// the longitudes of a rectilinear subset always increase; those of a 1-D
+// longitude must increase along their dimension. For curvilinear grids the
+// subset is the smallest index rectangle holding every point inside the
+// box, and may include points outside it.
+func (v Var) SubsetBBox(minLon, minLat, maxLon, maxLat float64) (*GeoSubset, error) {
+	if v.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke SubsetBBox on a Null variable")
+	}
+	if minLat > maxLat {
+		return nil, fmt.Errorf("error: SubsetBBox minLat %v is above maxLat %v", minLat, maxLat)
+	}
+	box := newLonRange(minLon, maxLon)
+
+	lonVar, latVar, err := v.lonLat()
+	if err != nil {
+		return nil, err
+	}
+	dims, err := v.GetDims()
+	if err != nil {
+		return nil, err
+	}
+	lonAxes, err := dimAxes(dims, lonVar)
+	if err != nil {
+		return nil, err
+	}
+	latAxes, err := dimAxes(dims, latVar)
+	if err != nil {
+		return nil, err
+	}
+	lon, err := lonVar.ReadUnpacked()
+	if err != nil {
+		return nil, err
+	}
+	lat, err := latVar.ReadUnpacked()
+	if err != nil {
+		return nil, err
+	}
+
+	// the index range along the latitude axis (rows) and the one or two
+	// ranges along the longitude axis (parts)
+	var xAxis, yAxis int
+	var rows Range
+	var parts []Range
+	rectilinear := len(lonAxes) == 1 && len(latAxes) == 1
+	switch {
+	case rectilinear:
+		xAxis, yAxis = lonAxes[0], latAxes[0]
+		if xAxis == yAxis {
+			return nil, fmt.Errorf("error: SubsetBBox needs a grid, latitude and longitude are along the same dimension")
+		}
+		if rows, err = latRows(lat.Data(), minLat, maxLat); err != nil {
+			return nil, err
+		}
+		if parts, err = box.parts(lon.Data()); err != nil {
+			return nil, err
+		}
+	case len(lonAxes) == 2 && len(latAxes) == 2 && lonAxes[0] == latAxes[0] && lonAxes[1] == latAxes[1]:
+		// the first dimension of the coordinates is taken as y
+		yAxis, xAxis = lonAxes[0], lonAxes[1]
+		if rows, parts, err = box.curvilinear(lon, lat, minLat, maxLat); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("error: SubsetBBox needs 1-D or 2-D latitude and longitude on the same dimensions")
+	}
+
+	// read the parts and join them along x
+	shape, err := v.Shape()
+	if err != nil {
+		return nil, err
+	}
+	var values *Array[float64]
+	for _, part := range parts {
+		start, count := make([]int, len(shape)), append([]int{}, shape...)
+		start[yAxis], count[yAxis] = rows.Start, rows.Stop-rows.Start
+		start[xAxis], count[xAxis] = part.Start, part.Stop-part.Start
+		data, err := v.ReadUnpackedSlab(start, count)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			values = data
+		} else {
+			values = concatAxis(values, data, xAxis)
+		}
+	}
+
+	da, err := newDataArray(v, values)
+	if err != nil {
+		return nil, err
+	}
+	s := &GeoSubset{DataArray: da}
+	if rectilinear {
+		s.Lat, err = lat.Slice(rows)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range parts {
+			p, err := lon.Slice(part)
+			if err != nil {
+				return nil, err
+			}
+			s.Lon = concatAxis(s.Lon, p, 0)
+		}
+		box.shift(s.Lon.Data())
+		s.Coords[da.Dims[yAxis]] = s.Lat.Data()
+		s.Coords[da.Dims[xAxis]] = s.Lon.Data()
+		return s, nil
+	}
+	// 2-D coordinates, indexed (y, x)
+	for _, part := range parts {
+		latPart, err := lat.Slice(rows, part)
+		if err != nil {
+			return nil, err
+		}
+		lonPart, err := lon.Slice(rows, part)
+		if err != nil {
+			return nil, err
+		}
+		s.Lat = concatAxis(s.Lat, latPart, 1)
+		s.Lon = concatAxis(s.Lon, lonPart, 1)
+	}
+	box.shift(s.Lon.Data())
+	// the 1-D coordinate variables of y and x, such as rlat and rlon
+	if y, ok := s.Coords[da.Dims[yAxis]]; ok {
+		s.Coords[da.Dims[yAxis]] = append([]float64{}, y[rows.Start:rows.Stop]...)
+	}
+	if x, ok := s.Coords[da.Dims[xAxis]]; ok {
+		var cut []float64
+		for _, part := range parts {
+			cut = append(cut, x[part.Start:part.Stop]...)
+		}
+		s.Coords[da.Dims[xAxis]] = cut
+	}
+	return s, nil
+}
+
+// lonLat finds the longitude and latitude among the coordinates of v.
+func (v Var) lonLat() (lon, lat Var, err error) {
+	coords, err := v.Coordinates()
+	if err != nil {
+		return lon, lat, err
+	}
+	var foundLon, foundLat bool
+	for _, c := range coords {
+		isLon, isLat, err := c.isLonLat()
+		if err != nil {
+			return lon, lat, err
+		}
+		if isLon && !foundLon {
+			lon, foundLon = c, true
+		}
+		if isLat && !foundLat {
+			lat, foundLat = c, true
+		}
+	}
+	if !foundLon || !foundLat {
+		return lon, lat, fmt.Errorf("error: no latitude and longitude coordinates")
+	}
+	return lon, lat, nil
+}
+
+// isLonLat tells whether v is a longitude or latitude by its standard_name
+// or units; grid_longitude, projection coordinates and the like are not.
+func (v Var) isLonLat() (isLon, isLat bool, err error) {
+	for _, name := range []string{"standard_name", "units"} {
+		att, err := v.GetAtt(name)
+		if err != nil {
+			continue
+		}
+		value, err := att.Text()
+		if err != nil {
+			return false, false, err
+		}
+		value = strings.TrimSpace(value)
+		switch value {
+		case "longitude":
+			return true, false, nil
+		case "latitude":
+			return false, true, nil
+		}
+		if name == "units" {
+			if axis, ok := unitsAxis(value); ok && (axis == AxisX || axis == AxisY) {
+				return axis == AxisX, axis == AxisY, nil
+			}
+		}
+	}
+	return false, false, nil
+}
+
+// dimAxes returns the positions in dims of the dimensions of c.
+func dimAxes(dims []Dim, c Var) ([]int, error) {
+	cDims, err := c.GetDims()
+	if err != nil {
+		return nil, err
+	}
+	axes := make([]int, len(cDims))
+	for i, cd := range cDims {
+		axes[i] = -1
+		for j, d := range dims {
+			if d.ID() == cd.ID() {
+				axes[i] = j
+			}
+		}
+		if axes[i] < 0 {
+			name, _ := c.GetName()
+			return nil, fmt.Errorf("error: coordinate %q is not along the dimensions of the variable", name)
+		}
+	}
+	return axes, nil
+}
+
+// latRows returns the index range of the latitudes in [minLat, maxLat].
+func latRows(lat []float64, minLat, maxLat float64) (Range, error) {
+	first, last := -1, -1
+	for i, y := range lat {
+		if y >= minLat && y <= maxLat {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return Range{}, fmt.Errorf("error: no latitudes in [%v, %v]", minLat, maxLat)
+	}
+	return Range{Start: first, Stop: last + 1}, nil
+}
+
+// lonRange is a longitude range, lo <= hi < lo+360 unless it covers the
+// whole circle.
+type lonRange struct {
+	lo, hi float64
+}
+
+func newLonRange(minLon, maxLon float64) lonRange {
+	r := lonRange{lo: minLon, hi: maxLon}
+	if r.hi < r.lo {
+		// crosses the antimeridian
+		r.hi += 360
+	}
+	if r.hi-r.lo >= 360 {
+		r.hi = r.lo + 360
+	}
+	return r
+}
+
+// wrap shifts lon by a multiple of 360 into [lo, lo+360).
+func (r lonRange) wrap(lon float64) float64 {
+	return r.lo + math.Mod(math.Mod(lon-r.lo, 360)+360, 360)
+}
+
+func (r lonRange) contains(lon float64) bool {
+	return r.wrap(lon) <= r.hi
+}
+
+// shift moves longitudes into the range of r, keeping them increasing
+// where the subset wraps.
+func (r lonRange) shift(lon []float64) {
+	for i, x := range lon {
+		lon[i] = r.wrap(x)
+	}
+}
+
+// parts returns the index ranges of a 1-D increasing longitude inside r,
+// in the order that keeps the longitudes increasing: the indices from
+// where the grid wraps past lo, then those from the start of the grid.
+func (r lonRange) parts(lon []float64) ([]Range, error) {
+	if len(lon) == 0 {
+		return nil, fmt.Errorf("error: empty longitude")
+	}
+	// k is where the shifted longitudes drop, if the grid crosses lo
+	k := 0
+	for i := 1; i < len(lon); i++ {
+		if !(lon[i] > lon[i-1]) {
+			return nil, fmt.Errorf("error: longitudes must increase, they do not at index %d", i)
+		}
+		if r.wrap(lon[i]) < r.wrap(lon[i-1]) {
+			if k != 0 {
+				return nil, fmt.Errorf("error: longitudes span more than 360 degrees")
+			}
+			k = i
+		}
+	}
+	var parts []Range
+	for _, seg := range []Range{{Start: k, Stop: len(lon)}, {Start: 0, Stop: k}} {
+		stop := seg.Start
+		for stop < seg.Stop && r.contains(lon[stop]) {
+			stop++
+		}
+		if stop > seg.Start {
+			parts = append(parts, Range{Start: seg.Start, Stop: stop})
+		}
+		if stop < seg.Stop {
+			// the rest of the grid is further east than the box
+			break
+		}
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("error: no longitudes in [%v, %v]", r.lo, r.hi)
+	}
+	return parts, nil
+}
+
+// curvilinear returns the rows and the one or two column ranges of 2-D
+// (y, x) coordinates that cover the points inside the box. Two ranges are
+// returned when the points are at both edges of the grid and not in the
+// middle, as for a box across the seam of a global grid.
+func (r lonRange) curvilinear(lon, lat *Array[float64], minLat, maxLat float64) (rows Range, parts []Range, err error) {
+	shape := lon.Shape()
+	ny, nx := shape[0], shape[1]
+	rows = Range{Start: ny, Stop: 0}
+	cols := make([]bool, nx)
+	for j := 0; j < ny; j++ {
+		for i := 0; i < nx; i++ {
+			y := lat.At(j, i)
+			if y >= minLat && y <= maxLat && r.contains(lon.At(j, i)) {
+				rows.Start, rows.Stop = min(rows.Start, j), max(rows.Stop, j+1)
+				cols[i] = true
+			}
+		}
+	}
+	if rows.Start >= rows.Stop {
+		return rows, nil, fmt.Errorf("error: no grid points in the bounding box")
+	}
+
+	// runs of columns holding points
+	var runs []Range
+	for i := 0; i < nx; i++ {
+		if !cols[i] {
+			continue
+		}
+		if len(runs) > 0 && runs[len(runs)-1].Stop == i {
+			runs[len(runs)-1].Stop = i + 1
+		} else {
+			runs = append(runs, Range{Start: i, Stop: i + 1})
+		}
+	}
+	if len(runs) == 2 && runs[0].Start == 0 && runs[1].Stop == nx {
+		return rows, []Range{runs[1], runs[0]}, nil
+	}
+	return rows, []Range{{Start: runs[0].Start, Stop: runs[len(runs)-1].Stop}}, nil
+}
+
+// concatAxis joins b after a along axis; the other dimensions must agree. A
+// nil a returns b.
+func concatAxis[T any](a, b *Array[T], axis int) *Array[T] {
+	if a == nil {
+		return b
+	}
+	shape := append([]int{}, a.shape...)
+	shape[axis] += b.shape[axis]
+	out := MakeArray[T](shape...)
+
+	// copy blocks of the inner dimensions, alternating between a and b
+	inner := shapeLen(a.shape[axis+1:])
+	blockA, blockB := a.shape[axis]*inner, b.shape[axis]*inner
+	outer := shapeLen(a.shape[:axis])
+	pos := 0
+	for i := 0; i < outer; i++ {
+		pos += copy(out.data[pos:], a.data[i*blockA:(i+1)*blockA])
+		pos += copy(out.data[pos:], b.data[i*blockB:(i+1)*blockB])
+	}
+	return out
+}
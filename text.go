@@ -0,0 +1,200 @@
+package netcdf4
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CharsToStrings splits a fixed width Char array into strings of width
+// characters each, trimming the trailing NUL padding.
+func CharsToStrings(chars []byte, width int) []string {
+	if width <= 0 {
+		return nil
+	}
+	strs := make([]string, len(chars)/width)
+	for i := range strs {
+		strs[i] = strings.TrimRight(string(chars[i*width:(i+1)*width]), "\x00")
+	}
+	return strs
+}
+
+// StringsToChars lays out strs as a fixed width Char array of width
+// characters per string, padding with NULs. A string longer than width is
+// an error.
+func StringsToChars(strs []string, width int) ([]byte, error) {
+	chars := make([]byte, len(strs)*width)
+	for i, str := range strs {
+		if len(str) > width {
+			return nil, fmt.Errorf("error: string %q is longer than %d characters", str, width)
+		}
+		copy(chars[i*width:], str)
+	}
+	return chars, nil
+}
+
+// GetStrings reads a text variable as strings. Each value of a String
+// variable is one string. A Char variable is taken as a fixed width array
+// whose last dimension is the string length: a (station, name_strlen)
+// variable gives one string per station with the NUL padding trimmed, and a
+// 1-D or scalar one gives a single string.
+func (v Var) GetStrings() ([]string, error) {
+	if v.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke GetStrings on a Null variable")
+	}
+	shape, err := v.stringShape()
+	if err != nil {
+		return nil, err
+	}
+	return v.GetStringsSlab(make([]int, len(shape)), shape)
+}
+
+// GetStringsSlab reads the strings of the hyperslab that begins at start and
+// spans count strings along each dimension, laid out as for GetStrings. For
+// a Char variable start and count leave out the string length dimension,
+// which is read whole.
+func (v Var) GetStringsSlab(start, count []int) ([]string, error) {
+	if v.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke GetStringsSlab on a Null variable")
+	}
+	t, err := v.GetType()
+	if err != nil {
+		return nil, err
+	}
+	shape, err := v.Shape()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.GetId() {
+	case String.GetId():
+		if len(start) != len(shape) || len(count) != len(shape) {
+			return nil, fmt.Errorf("error: GetStringsSlab needs start and count of length %d", len(shape))
+		}
+		return ncGetVaraString(v.groupId, v.myId, toSizeT(start), toSizeT(count))
+	case Char.GetId():
+		width, nStrs := 1, 1
+		if len(shape) > 0 {
+			width = shape[len(shape)-1]
+			if len(start) != len(shape)-1 || len(count) != len(shape)-1 {
+				return nil, fmt.Errorf("error: GetStringsSlab needs start and count of length %d", len(shape)-1)
+			}
+			for _, size := range count {
+				nStrs *= size
+			}
+			start, count = append(append([]int{}, start...), 0), append(append([]int{}, count...), width)
+		}
+		if width == 0 || nStrs == 0 {
+			return make([]string, nStrs), nil
+		}
+		chars := make([]byte, nStrs*width)
+		if err := ncGetVaraText(v.groupId, v.myId, toSizeT(start), toSizeT(count), chars); err != nil {
+			return nil, err
+		}
+		return CharsToStrings(chars, width), nil
+	default:
+		return nil, fmt.Errorf("error: GetStringsSlab needs a String or Char variable")
+	}
+}
+
+// stringShape returns the shape of the strings of a text variable: its
+// shape, less the string length dimension of a Char variable.
+func (v Var) stringShape() ([]int, error) {
+	t, err := v.GetType()
+	if err != nil {
+		return nil, err
+	}
+	shape, err := v.Shape()
+	if err != nil {
+		return nil, err
+	}
+	if t.GetId() == Char.GetId() && len(shape) > 0 {
+		shape = shape[:len(shape)-1]
+	}
+	return shape, nil
+}
+
+// PutStrings writes strings into a text variable, laid out as described for
+// GetStrings; Char values are padded with NULs. data must fill the variable
+// except along an unlimited dimension, whose extent follows from len(data).
+// Records are written from record 0, overwriting those already written; it
+// does not append, and records beyond len(data) are left as they are.
+func (v Var) PutStrings(data []string) error {
+	if v.IsNull() {
+		return fmt.Errorf("error: attempt to invoke PutStrings on a Null variable")
+	}
+	t, err := v.GetType()
+	if err != nil {
+		return err
+	}
+	dims, err := v.GetDims()
+	if err != nil {
+		return err
+	}
+	isChar := t.GetId() == Char.GetId()
+	if !isChar && t.GetId() != String.GetId() {
+		return fmt.Errorf("error: PutStrings needs a String or Char variable")
+	}
+
+	// the dimensions holding one string per value
+	strDims, width := dims, 1
+	if isChar && len(dims) > 0 {
+		strDims = dims[:len(dims)-1]
+		if width, err = dims[len(dims)-1].GetSize(); err != nil {
+			return err
+		}
+	}
+	recIdx, err := recordDimIndex(strDims)
+	if err != nil {
+		return err
+	}
+	var start, count []int
+	if recIdx >= 0 {
+		if start, count, err = recordSlab(strDims, recIdx, 0, data); err != nil {
+			return err
+		}
+	} else {
+		start = make([]int, len(strDims))
+		count = make([]int, len(strDims))
+		for i, dim := range strDims {
+			if count[i], err = dim.GetSize(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := CheckDataMode(v.groupId); err != nil {
+		return err
+	}
+	if !isChar {
+		return ncPutVaraString(v.groupId, v.myId, toSizeT(start), toSizeT(count), data)
+	}
+	nStrs := 1
+	for _, c := range count {
+		nStrs *= c
+	}
+	if len(data) != nStrs {
+		return fmt.Errorf("error: PutStrings got %d strings for %d values", len(data), nStrs)
+	}
+	chars, err := StringsToChars(data, width)
+	if err != nil {
+		return err
+	}
+	if len(dims) > 0 {
+		start = append(start, 0)
+		count = append(count, width)
+	}
+	return ncPutVaraText(v.groupId, v.myId, toSizeT(start), toSizeT(count), chars)
+}
+
+// ScalarString reads a single string: the value of a scalar String
+// variable, or the text of a scalar or 1-D Char variable.
+func (v Var) ScalarString() (string, error) {
+	strs, err := v.GetStrings()
+	if err != nil {
+		return "", err
+	}
+	if len(strs) != 1 {
+		return "", fmt.Errorf("error: ScalarString needs a variable holding one string, found %d", len(strs))
+	}
+	return strs[0], nil
+}
@@ -3,6 +3,7 @@ package netcdf4
 // #include <stdlib.h>
 // #include <netcdf.h>
 import "C"
+import "fmt"
 
 type NcType C.nc_type
 
@@ -89,3 +90,112 @@ func (t Type) IsComplex() bool {
 	}
 
 }
+
+// newSlice returns a slice of n values of the Go type matching t, e.g.
+// []float32 for Float. Only numeric types are supported.
+func (t Type) newSlice(n int) (interface{}, error) {
+	switch t.myId {
+	case C.NC_BYTE:
+		return make([]int8, n), nil
+	case C.NC_UBYTE:
+		return make([]uint8, n), nil
+	case C.NC_SHORT:
+		return make([]int16, n), nil
+	case C.NC_USHORT:
+		return make([]uint16, n), nil
+	case C.NC_INT:
+		return make([]int32, n), nil
+	case C.NC_UINT:
+		return make([]uint32, n), nil
+	case C.NC_INT64:
+		return make([]int64, n), nil
+	case C.NC_UINT64:
+		return make([]uint64, n), nil
+	case C.NC_FLOAT:
+		return make([]float32, n), nil
+	case C.NC_DOUBLE:
+		return make([]float64, n), nil
+	default:
+		return nil, fmt.Errorf("error: no numeric Go type for netCDF type %d", t.myId)
+	}
+}
+
+// sliceType returns the netCDF type matching the element type of data, a
+// slice of a fixed size numeric type.
+func sliceType(data interface{}) (Type, error) {
+	switch data.(type) {
+	case []int8:
+		return Byte, nil
+	case []uint8:
+		return Ubyte, nil
+	case []int16:
+		return Short, nil
+	case []uint16:
+		return Ushort, nil
+	case []int32:
+		return Int, nil
+	case []uint32:
+		return Uint, nil
+	case []int64:
+		return Int64, nil
+	case []uint64:
+		return Uint64, nil
+	case []float32:
+		return Float, nil
+	case []float64:
+		return Double, nil
+	default:
+		return NewTypeNull(), fmt.Errorf("error: no netCDF type for %T", data)
+	}
+}
+
+// intBits returns the size in bits of an integer type and whether it is
+// signed; ok is false for other types.
+func (t Type) intBits() (bits int, signed bool, ok bool) {
+	switch t.myId {
+	case C.NC_BYTE:
+		return 8, true, true
+	case C.NC_UBYTE:
+		return 8, false, true
+	case C.NC_SHORT:
+		return 16, true, true
+	case C.NC_USHORT:
+		return 16, false, true
+	case C.NC_INT:
+		return 32, true, true
+	case C.NC_UINT:
+		return 32, false, true
+	case C.NC_INT64:
+		return 64, true, true
+	case C.NC_UINT64:
+		return 64, false, true
+	default:
+		return 0, false, false
+	}
+}
+
+// defaultFill returns the default fill value of a numeric type, the value
+// unwritten data reads as. The byte types have none by convention, since all
+// their values are commonly used.
+func (t Type) defaultFill() (float64, bool) {
+	switch t.myId {
+	case C.NC_SHORT:
+		return C.NC_FILL_SHORT, true
+	case C.NC_USHORT:
+		return C.NC_FILL_USHORT, true
+	case C.NC_INT:
+		return C.NC_FILL_INT, true
+	case C.NC_UINT:
+		return C.NC_FILL_UINT, true
+	case C.NC_INT64:
+		return C.NC_FILL_INT64, true
+	case C.NC_UINT64:
+		return C.NC_FILL_UINT64, true
+	case C.NC_FLOAT:
+		return float64(float32(C.NC_FILL_FLOAT)), true
+	case C.NC_DOUBLE:
+		return C.NC_FILL_DOUBLE, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,477 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// parser is a recursive descent parser of the UDUNITS-2 grammar:
+//
+//	unit    = product [shift (number | timestamp)]
+//	product = power {["*" | "." | " "] power | ("/" | "per") power}
+//	power   = basic [("^" | "**") integer | integer]
+//	basic   = name | number | "(" unit ")"
+//
+// where shift is "@", "since", "after", "from" or "ref", and an integer
+// right after a name, as in "m2" or "s-1", is its exponent.
+type parser struct {
+	s   string
+	pos int
+}
+
+func parse(s string) (Unit, error) {
+	p := &parser{s: strings.TrimSpace(s)}
+	if p.s == "" {
+		return newUnit(big.NewRat(1, 1)), nil
+	}
+	u, err := p.unit()
+	if err != nil {
+		return Unit{}, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return Unit{}, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return u, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("error: units %q: %s", p.s, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// peekRune returns the next rune without consuming it, utf8.RuneError at
+// the end.
+func (p *parser) peekRune() rune {
+	if p.pos >= len(p.s) {
+		return utf8.RuneError
+	}
+	r, _ := utf8.DecodeRuneInString(p.s[p.pos:])
+	return r
+}
+
+// keyword consumes the word w, case insensitively, if it is next and not
+// the start of a longer name.
+func (p *parser) keyword(w string) bool {
+	end := p.pos + len(w)
+	if end > len(p.s) || !strings.EqualFold(p.s[p.pos:end], w) {
+		return false
+	}
+	if end < len(p.s) {
+		if r, _ := utf8.DecodeRuneInString(p.s[end:]); isNameRune(r) {
+			return false
+		}
+	}
+	p.pos = end
+	return true
+}
+
+func (p *parser) unit() (Unit, error) {
+	u, err := p.product()
+	if err != nil {
+		return Unit{}, err
+	}
+	p.skipSpace()
+	op := ""
+	for _, w := range []string{"@", "since", "after", "from", "ref"} {
+		if p.keyword(w) {
+			op = w
+			break
+		}
+	}
+	if op == "" {
+		return u, nil
+	}
+	p.skipSpace()
+
+	rest := p.s[p.pos:]
+	if end := strings.IndexByte(rest, ')'); end >= 0 {
+		rest = rest[:end]
+	}
+	if u.IsTime() && (op != "@" && op != "ref" || looksLikeTimestamp(rest)) {
+		if u.offset.Sign() != 0 || u.timestamp {
+			return Unit{}, p.errorf("unit already has an origin")
+		}
+		ref, err := parseTimestamp(strings.TrimSpace(rest))
+		if err != nil {
+			return Unit{}, p.errorf("%v", err)
+		}
+		p.pos += len(rest)
+		u.offset = new(big.Rat).SetFloat64(ref)
+		u.timestamp = true
+		return u, nil
+	}
+	if op != "@" && op != "ref" {
+		return Unit{}, p.errorf("%q needs a unit of time", op)
+	}
+	origin, ok := p.number()
+	if !ok {
+		return Unit{}, p.errorf("expected a number after %q", op)
+	}
+	u.offset = new(big.Rat).Add(u.offset, new(big.Rat).Mul(origin, u.scale))
+	return u, nil
+}
+
+func (p *parser) product() (Unit, error) {
+	u, err := p.power()
+	if err != nil {
+		return Unit{}, err
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return u, nil
+		}
+		start := p.pos
+		divide := false
+		switch c := p.s[p.pos]; {
+		case c == '/':
+			divide = true
+			p.pos++
+		case c == '*' || c == '.':
+			p.pos++
+			if c == '*' && p.pos < len(p.s) && p.s[p.pos] == '*' {
+				return Unit{}, p.errorf("misplaced \"**\"")
+			}
+		case strings.HasPrefix(p.s[p.pos:], "·"):
+			p.pos += len("·")
+		case p.keyword("per"):
+			divide = true
+		case c == ')' || c == '@':
+			return u, nil
+		default:
+			for _, w := range []string{"since", "after", "from", "ref"} {
+				if p.keyword(w) {
+					p.pos = start
+					return u, nil
+				}
+			}
+		}
+		p.skipSpace()
+		v, err := p.power()
+		if err != nil {
+			return Unit{}, err
+		}
+		if divide {
+			if v.scale.Sign() == 0 {
+				return Unit{}, p.errorf("division by zero")
+			}
+			v = v.pow(-1)
+		}
+		if v.offset.Sign() != 0 && u.dims == [nBase]int{} && u.offset.Sign() == 0 && !divide {
+			// a number times a shifted unit, e.g. "1000 degC"
+			u = v.scaleBy(u.scale)
+		} else {
+			u = u.mul(v)
+		}
+	}
+}
+
+func (p *parser) power() (Unit, error) {
+	u, isName, err := p.basic()
+	if err != nil {
+		return Unit{}, err
+	}
+	n, ok := 0, false
+	switch {
+	case strings.HasPrefix(p.s[p.pos:], "^"):
+		p.pos++
+		if n, ok = p.integer(); !ok {
+			return Unit{}, p.errorf("expected an integer exponent")
+		}
+	case strings.HasPrefix(p.s[p.pos:], "**"):
+		p.pos += 2
+		if n, ok = p.integer(); !ok {
+			return Unit{}, p.errorf("expected an integer exponent")
+		}
+	case isName:
+		n, ok = p.integer()
+		if !ok {
+			n, ok = p.superscript()
+		}
+	}
+	if !ok || n == 1 {
+		return u, nil
+	}
+	if n < 0 && u.scale.Sign() == 0 {
+		return Unit{}, p.errorf("division by zero")
+	}
+	return u.pow(n), nil
+}
+
+func (p *parser) basic() (u Unit, isName bool, err error) {
+	if p.pos >= len(p.s) {
+		return Unit{}, false, p.errorf("unexpected end")
+	}
+	r := p.peekRune()
+	switch {
+	case r == '(':
+		p.pos++
+		p.skipSpace()
+		if u, err = p.unit(); err != nil {
+			return Unit{}, false, err
+		}
+		p.skipSpace()
+		if !strings.HasPrefix(p.s[p.pos:], ")") {
+			return Unit{}, false, p.errorf("missing \")\"")
+		}
+		p.pos++
+		return u, false, nil
+	case r == '+' || r == '-' || r == '.' || (r >= '0' && r <= '9'):
+		x, ok := p.number()
+		if !ok {
+			return Unit{}, false, p.errorf("invalid number at %q", p.s[p.pos:])
+		}
+		return newUnit(x), false, nil
+	case isNameRune(r):
+		start := p.pos
+		for p.pos < len(p.s) {
+			r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+			if !isNameRune(r) {
+				break
+			}
+			p.pos += size
+		}
+		name := p.s[start:p.pos]
+		u, ok := lookup(name)
+		if !ok {
+			return Unit{}, false, p.errorf("unknown unit %q", name)
+		}
+		return u, true, nil
+	}
+	return Unit{}, false, p.errorf("unexpected %q", p.s[p.pos:])
+}
+
+// isNameRune tells the runes of unit names; digits are not, those after a
+// name are its exponent.
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == '%' || r == '°'
+}
+
+// integer consumes an optionally signed integer.
+func (p *parser) integer() (int, bool) {
+	end := p.pos
+	if end < len(p.s) && (p.s[end] == '+' || p.s[end] == '-') {
+		end++
+	}
+	digits := end
+	for end < len(p.s) && p.s[end] >= '0' && p.s[end] <= '9' {
+		end++
+	}
+	if end == digits {
+		return 0, false
+	}
+	n, err := strconv.Atoi(p.s[p.pos:end])
+	if err != nil {
+		return 0, false
+	}
+	p.pos = end
+	return n, true
+}
+
+var superscripts = map[rune]int{'⁰': 0, '¹': 1, '²': 2, '³': 3, '⁴': 4, '⁵': 5, '⁶': 6, '⁷': 7, '⁸': 8, '⁹': 9}
+
+// superscript consumes an exponent written with superscript digits, as in
+// "m²" or "s⁻¹".
+func (p *parser) superscript() (int, bool) {
+	pos, sign, n, digits := p.pos, 1, 0, 0
+	if strings.HasPrefix(p.s[pos:], "⁻") {
+		sign, pos = -1, pos+len("⁻")
+	}
+	for pos < len(p.s) {
+		r, size := utf8.DecodeRuneInString(p.s[pos:])
+		d, ok := superscripts[r]
+		if !ok {
+			break
+		}
+		n, digits, pos = n*10+d, digits+1, pos+size
+	}
+	if digits == 0 {
+		return 0, false
+	}
+	p.pos = pos
+	return sign * n, true
+}
+
+// number consumes a real number such as "1", "-2.5" or "1e-3", exactly.
+func (p *parser) number() (*big.Rat, bool) {
+	end := p.pos
+	if end < len(p.s) && (p.s[end] == '+' || p.s[end] == '-') {
+		end++
+	}
+	mantissa := end
+	for end < len(p.s) && (p.s[end] >= '0' && p.s[end] <= '9' || p.s[end] == '.') {
+		end++
+	}
+	if end == mantissa {
+		return nil, false
+	}
+	if end < len(p.s) && (p.s[end] == 'e' || p.s[end] == 'E') {
+		exp := end + 1
+		if exp < len(p.s) && (p.s[exp] == '+' || p.s[exp] == '-') {
+			exp++
+		}
+		digits := exp
+		for exp < len(p.s) && p.s[exp] >= '0' && p.s[exp] <= '9' {
+			exp++
+		}
+		if exp > digits {
+			end = exp
+		}
+	}
+	// the float64 range bounds the size of the rational
+	if f, err := strconv.ParseFloat(p.s[p.pos:end], 64); err != nil || math.IsInf(f, 0) {
+		return nil, false
+	}
+	x, ok := new(big.Rat).SetString(p.s[p.pos:end])
+	if !ok {
+		return nil, false
+	}
+	p.pos = end
+	return x, true
+}
+
+// looksLikeTimestamp tells a reference time from a plain number after "@".
+func looksLikeTimestamp(s string) bool {
+	s = strings.TrimLeft(strings.TrimSpace(s), "+-")
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return i > 0 && i < len(s) && (s[i] == '-' || s[i] == ':')
+}
+
+// parseTimestamp parses a reference time such as "1970-01-01",
+// "2000-1-1 12:00:00.5", "1990-01-01T00:00Z" or "2001-06-01 00:00:00 -6:00"
+// and returns it as seconds since 1970-01-01 00:00:00 UTC.
+func parseTimestamp(s string) (float64, error) {
+	bad := fmt.Errorf("invalid reference time %q", s)
+	rest := s
+	sign := int64(1)
+	if strings.HasPrefix(rest, "-") {
+		sign, rest = -1, rest[1:]
+	} else if strings.HasPrefix(rest, "+") {
+		rest = rest[1:]
+	}
+
+	// date
+	field := func(sep string) (int64, bool) {
+		i := strings.IndexAny(rest, sep)
+		if i < 0 {
+			i = len(rest)
+		}
+		n, err := strconv.ParseInt(rest[:i], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		rest = rest[i:]
+		return n, true
+	}
+	year, ok := field("- T")
+	if !ok {
+		return 0, bad
+	}
+	year *= sign
+	month, day := int64(1), int64(1)
+	if strings.HasPrefix(rest, "-") {
+		rest = rest[1:]
+		if month, ok = field("- T"); !ok {
+			return 0, bad
+		}
+		if strings.HasPrefix(rest, "-") {
+			rest = rest[1:]
+			if day, ok = field(" T"); !ok {
+				return 0, bad
+			}
+		}
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return 0, bad
+	}
+	seconds := float64(daysFromCivil(year, month, day)) * 86400
+
+	// time of day
+	rest = strings.TrimLeft(rest, " ")
+	rest = strings.TrimPrefix(rest, "T")
+	if rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+		end := 0
+		for end < len(rest) && (rest[end] >= '0' && rest[end] <= '9' || rest[end] == ':' || rest[end] == '.') {
+			end++
+		}
+		parts := strings.Split(rest[:end], ":")
+		if len(parts) > 3 {
+			return 0, bad
+		}
+		scales := []float64{3600, 60, 1}
+		for i, part := range parts {
+			x, err := strconv.ParseFloat(part, 64)
+			if err != nil || x < 0 {
+				return 0, bad
+			}
+			seconds += x * scales[i]
+		}
+		rest = rest[end:]
+	}
+
+	// time zone
+	rest = strings.TrimSpace(rest)
+	switch strings.ToUpper(rest) {
+	case "", "Z", "UTC", "GMT":
+		return seconds, nil
+	}
+	if rest[0] != '+' && rest[0] != '-' {
+		return 0, bad
+	}
+	zoneSign := 1.0
+	if rest[0] == '-' {
+		zoneSign = -1
+	}
+	zone := rest[1:]
+	var hours, minutes int
+	var err error
+	if h, m, ok := strings.Cut(zone, ":"); ok {
+		hours, err = strconv.Atoi(h)
+		if err == nil {
+			minutes, err = strconv.Atoi(m)
+		}
+	} else if len(zone) > 2 {
+		hours, err = strconv.Atoi(zone[:len(zone)-2])
+		if err == nil {
+			minutes, err = strconv.Atoi(zone[len(zone)-2:])
+		}
+	} else {
+		hours, err = strconv.Atoi(zone)
+	}
+	if err != nil || hours > 14 || minutes > 59 {
+		return 0, bad
+	}
+	// local time minus the zone offset is UTC
+	return seconds - zoneSign*float64(hours*3600+minutes*60), nil
+}
+
+// daysFromCivil returns the number of days from 1970-01-01 to a date of the
+// proleptic Gregorian calendar.
+func daysFromCivil(y, m, d int64) int64 {
+	if m <= 2 {
+		y--
+	}
+	era := y / 400
+	if y < 0 && y%400 != 0 {
+		era--
+	}
+	yoe := y - era*400
+	mp := (m + 9) % 12
+	doy := (153*mp+2)/5 + d - 1
+	doe := yoe*365 + yoe/4 - yoe/100 + doy
+	return era*146097 + doe - 719468
+}
@@ -0,0 +1,191 @@
+package units
+
+import (
+	"math/big"
+	"strings"
+)
+
+// unitDef defines a unit by its symbol, names and definition in terms of
+// units defined before it. Names may take prefix names and a plural "s",
+// symbols prefix symbols.
+type unitDef struct {
+	symbols []string
+	names   []string
+	def     string
+}
+
+// unitDefs are the known units, after the base units. Most come from the
+// UDUNITS-2 database.
+var unitDefs = []unitDef{
+	// dimensionless and angles
+	{[]string{"rad"}, []string{"radian"}, "1"},
+	{[]string{"sr"}, []string{"steradian"}, "1"},
+	{[]string{"%"}, []string{"percent"}, "0.01"},
+	{[]string{"ppm"}, nil, "1e-6"},
+	{[]string{"ppb"}, nil, "1e-9"},
+	{[]string{"°"}, []string{"degree", "arc_degree", "angular_degree"}, "3.14159265358979323846/180 rad"},
+	{[]string{"arcmin"}, []string{"arc_minute", "angular_minute"}, "1/60 degree"},
+	{[]string{"arcsec"}, []string{"arc_second", "angular_second"}, "1/60 arcmin"},
+	{nil, []string{"degree_north", "degrees_north", "degree_N", "degrees_N", "degreeN", "degreesN",
+		"degree_east", "degrees_east", "degree_E", "degrees_E", "degreeE", "degreesE",
+		"degree_true", "degrees_true", "degree_west", "degrees_west", "degree_south", "degrees_south"}, "degree"},
+
+	// SI derived units
+	{[]string{"g"}, []string{"gram", "gramme"}, "1e-3 kg"},
+	{[]string{"Hz"}, []string{"hertz"}, "s-1"},
+	{[]string{"N"}, []string{"newton"}, "kg m s-2"},
+	{[]string{"Pa"}, []string{"pascal"}, "N m-2"},
+	{[]string{"J"}, []string{"joule"}, "N m"},
+	{[]string{"W"}, []string{"watt"}, "J s-1"},
+	{[]string{"C"}, []string{"coulomb"}, "A s"},
+	{[]string{"V"}, []string{"volt"}, "W A-1"},
+	{[]string{"F"}, []string{"farad"}, "C V-1"},
+	{[]string{"Ω"}, []string{"ohm"}, "V A-1"},
+	{[]string{"S"}, []string{"siemens"}, "A V-1"},
+	{[]string{"Wb"}, []string{"weber"}, "V s"},
+	{[]string{"T"}, []string{"tesla"}, "Wb m-2"},
+	{[]string{"H"}, []string{"henry"}, "Wb A-1"},
+	{[]string{"lm"}, []string{"lumen"}, "cd sr"},
+	{[]string{"lx"}, []string{"lux"}, "lm m-2"},
+	{[]string{"Bq"}, []string{"becquerel"}, "s-1"},
+	{[]string{"Gy"}, []string{"gray"}, "J kg-1"},
+	{[]string{"Sv"}, []string{"sievert"}, "J kg-1"},
+	{[]string{"kat"}, []string{"katal"}, "mol s-1"},
+
+	// temperature
+	{[]string{"°C", "degC", "deg_C", "degreeC", "degreesC"}, []string{"celsius", "degree_Celsius", "degrees_Celsius", "degree_C", "degrees_C"}, "K @ 273.15"},
+	{[]string{"degK", "deg_K", "degreeK", "degreesK"}, []string{"degree_K", "degrees_K", "degree_Kelvin", "degrees_Kelvin"}, "K"},
+	{[]string{"°R", "degR", "deg_R"}, []string{"rankine", "degree_Rankine", "degrees_Rankine", "degree_R", "degrees_R"}, "K/1.8"},
+	{[]string{"°F", "degF", "deg_F", "degreeF", "degreesF"}, []string{"fahrenheit", "degree_Fahrenheit", "degrees_Fahrenheit", "degree_F", "degrees_F"}, "degR @ 459.67"},
+
+	// time
+	{[]string{"min"}, []string{"minute"}, "60 s"},
+	{[]string{"h", "hr"}, []string{"hour"}, "60 min"},
+	{[]string{"d"}, []string{"day"}, "24 h"},
+	{nil, []string{"week"}, "7 day"},
+	{[]string{"a", "yr"}, []string{"year"}, "3.15569259747e7 s"},
+	{nil, []string{"month"}, "1/12 year"},
+
+	// length, area, volume
+	{[]string{"Å"}, []string{"angstrom"}, "1e-10 m"},
+	{[]string{"in"}, []string{"inch", "inches"}, "0.0254 m"},
+	{[]string{"ft"}, []string{"foot", "feet"}, "12 in"},
+	{[]string{"mi"}, []string{"mile"}, "5280 ft"},
+	{[]string{"nmi"}, []string{"nautical_mile"}, "1852 m"},
+	{nil, []string{"fathom"}, "6 ft"},
+	{[]string{"ha"}, []string{"hectare"}, "1e4 m2"},
+	{[]string{"L", "l"}, []string{"liter", "litre"}, "1e-3 m3"},
+
+	// mass, speed, pressure, energy
+	{[]string{"t"}, []string{"tonne", "metric_ton"}, "1000 kg"},
+	{[]string{"u", "Da"}, []string{"dalton", "atomic_mass_unit"}, "1.66053906660e-27 kg"},
+	{[]string{"kt", "kn"}, []string{"knot"}, "1 nmi h-1"},
+	{[]string{"bar"}, []string{"bar"}, "1e5 Pa"},
+	{[]string{"atm"}, []string{"atmosphere"}, "101325 Pa"},
+	{[]string{"mmHg"}, []string{"millimeter_Hg", "mm_Hg"}, "133.322387415 Pa"},
+	{[]string{"Torr"}, []string{"torr"}, "101325/760 Pa"},
+	{[]string{"psi"}, nil, "6894.757293168 Pa"},
+	{[]string{"cal"}, []string{"calorie"}, "4.1868 J"},
+	{[]string{"erg"}, []string{"erg"}, "1e-7 J"},
+	{[]string{"dyn"}, []string{"dyne"}, "1e-5 N"},
+	{[]string{"eV"}, []string{"electronvolt"}, "1.602176634e-19 J"},
+	{nil, []string{"langley"}, "1e4 cal m-2"},
+
+	// others common in CF files
+	{nil, []string{"sverdrup"}, "1e6 m3 s-1"},
+	{[]string{"psu", "PSU"}, []string{"practical_salinity_unit"}, "1e-3"},
+	{[]string{"DU"}, []string{"dobson", "dobson_unit"}, "2.6867e20 m-2"},
+}
+
+// prefixes are the SI prefixes, by symbol, name and power of ten.
+var prefixes = []struct {
+	symbol, name string
+	exp          int
+}{
+	{"Y", "yotta", 24}, {"Z", "zetta", 21}, {"E", "exa", 18}, {"P", "peta", 15},
+	{"T", "tera", 12}, {"G", "giga", 9}, {"M", "mega", 6}, {"k", "kilo", 3},
+	{"h", "hecto", 2}, {"da", "deka", 1}, {"", "deca", 1}, {"d", "deci", -1},
+	{"c", "centi", -2}, {"m", "milli", -3}, {"µ", "micro", -6}, {"μ", "", -6},
+	{"u", "", -6}, {"n", "nano", -9}, {"p", "pico", -12}, {"f", "femto", -15},
+	{"a", "atto", -18}, {"z", "zepto", -21}, {"y", "yocto", -24},
+}
+
+var (
+	bySymbol = map[string]Unit{}
+	byName   = map[string]Unit{} // lower case
+)
+
+func init() {
+	base := [nBase][]string{
+		{"meter", "metre"}, {"kilogram"}, {"second", "sec"}, {"ampere", "amp"},
+		{"kelvin"}, {"mole"}, {"candela"},
+	}
+	for i, symbol := range baseSymbols {
+		u := newUnit(big.NewRat(1, 1))
+		u.dims[i] = 1
+		bySymbol[symbol] = u
+		for _, name := range base[i] {
+			byName[name] = u
+		}
+	}
+	for _, d := range unitDefs {
+		u, err := parse(d.def)
+		if err != nil {
+			panic(err)
+		}
+		for _, symbol := range d.symbols {
+			bySymbol[symbol] = u
+		}
+		for _, name := range d.names {
+			byName[strings.ToLower(name)] = u
+		}
+	}
+}
+
+// lookup finds a unit by symbol or name, with a prefix and, for names, a
+// plural "s".
+func lookup(name string) (Unit, bool) {
+	if u, ok := bySymbol[name]; ok {
+		return u, true
+	}
+	if u, ok := lookupName(strings.ToLower(name)); ok {
+		return u, true
+	}
+	for _, p := range prefixes {
+		if p.symbol != "" && len(name) > len(p.symbol) && strings.HasPrefix(name, p.symbol) {
+			if u, ok := bySymbol[name[len(p.symbol):]]; ok {
+				return prefixed(u, p.exp), true
+			}
+		}
+	}
+	lower := strings.ToLower(name)
+	for _, p := range prefixes {
+		if p.name != "" && len(lower) > len(p.name) && strings.HasPrefix(lower, p.name) {
+			if u, ok := lookupName(lower[len(p.name):]); ok {
+				return prefixed(u, p.exp), true
+			}
+		}
+	}
+	return Unit{}, false
+}
+
+// lookupName finds a lower case name, or its singular.
+func lookupName(name string) (Unit, bool) {
+	if u, ok := byName[name]; ok {
+		return u, true
+	}
+	if singular, ok := strings.CutSuffix(name, "s"); ok {
+		u, ok := byName[singular]
+		return u, ok
+	}
+	return Unit{}, false
+}
+
+// prefixed scales u by the power of ten of a prefix.
+func prefixed(u Unit, exp int) Unit {
+	factor := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(exp, -exp))), nil))
+	if exp < 0 {
+		factor.Inv(factor)
+	}
+	return u.scaleBy(factor)
+}
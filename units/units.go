@@ -0,0 +1,227 @@
+// Package units parses unit strings in the UDUNITS-2 syntax used by the
+// 'units' attribute of CF files, such as "kg m-2 s-1", "hPa", "degC" or
+// "days since 2000-01-01", and converts values between compatible units.
+//
+// It is written in Go and knows the SI units with their prefixes and the
+// common derived and non-SI units of the geosciences, a subset of the
+// UDUNITS-2 database. Conversions are dimensional: "mm day-1" and
+// "kg m-2 s-1" are not convertible without the density of water, which the
+// caller has to supply.
+//
+// Reference times of "since" units are read in the proleptic Gregorian
+// calendar; use netcdf4.DecodeTimes for the other CF calendars.
+package units
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// The base quantities of the SI
+const (
+	length = iota
+	mass
+	timeDim
+	current
+	temperature
+	amount
+	luminosity
+	nBase
+)
+
+// baseSymbols are the symbols of the base units, in the order above.
+var baseSymbols = [nBase]string{"m", "kg", "s", "A", "K", "mol", "cd"}
+
+// Unit is a parsed unit. A value x in the unit is x*scale + offset in the
+// SI base units of its dimensions; the offset is that of units such as degC,
+// and for time units with a reference time it is the number of seconds
+// from 1970-01-01 00:00:00 UTC to the reference. Both are exact rationals,
+// so that conversions such as degC to degF have exact factors; they are
+// never modified in place, as units share them. Units are made by Parse;
+// the zero Unit is not usable.
+type Unit struct {
+	text   string
+	scale  *big.Rat
+	offset *big.Rat
+	dims   [nBase]int
+	// timestamp is true for units with a reference time, e.g. "days since
+	// 2000-01-01"; they only convert to each other.
+	timestamp bool
+}
+
+// Parse parses a unit string. The empty string and "1" are dimensionless.
+func Parse(s string) (Unit, error) {
+	u, err := parse(s)
+	if err != nil {
+		return Unit{}, err
+	}
+	u.text = strings.TrimSpace(s)
+	return u, nil
+}
+
+// MustParse is like Parse but panics on an error; it is meant for unit
+// strings that are constants of the program.
+func MustParse(s string) Unit {
+	u, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// String returns the unit string as parsed.
+func (u Unit) String() string {
+	return u.text
+}
+
+// Base returns the unit in SI base units, e.g. "0.001 m-2 kg s-1" for
+// "g m-2 s-1", with "@ offset" for shifted units and, in seconds since
+// 1970-01-01, for units with a reference time.
+func (u Unit) Base() string {
+	var b strings.Builder
+	if u.scale.Cmp(one) != 0 || u.Dimensionless() {
+		b.WriteString(strconv.FormatFloat(ratFloat(u.scale), 'g', -1, 64))
+	}
+	for i, p := range u.dims {
+		if p == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(baseSymbols[i])
+		if p != 1 {
+			b.WriteString(strconv.Itoa(p))
+		}
+	}
+	if u.offset.Sign() != 0 || u.timestamp {
+		fmt.Fprintf(&b, " @ %s", strconv.FormatFloat(ratFloat(u.offset), 'g', -1, 64))
+	}
+	return b.String()
+}
+
+// Dimensionless returns true if the unit has no dimensions, such as "1",
+// "percent" or "radian".
+func (u Unit) Dimensionless() bool {
+	return u.dims == [nBase]int{}
+}
+
+// IsTime returns true for units of time, with or without a reference time.
+func (u Unit) IsTime() bool {
+	return u.dims == [nBase]int{timeDim: 1}
+}
+
+// HasReference returns true for time units with a reference time, such as
+// "hours since 1900-01-01".
+func (u Unit) HasReference() bool {
+	return u.timestamp
+}
+
+// ConvertibleTo returns true if values in u can be converted to to: both
+// have the same dimensions and either both or neither have a reference time.
+func (u Unit) ConvertibleTo(to Unit) bool {
+	return u.dims == to.dims && u.timestamp == to.timestamp
+}
+
+// Converter converts values between two units: Convert(x) is
+// x*Scale + Offset.
+type Converter struct {
+	Scale, Offset float64
+}
+
+// Converter returns the Converter from u to to.
+func (u Unit) Converter(to Unit) (Converter, error) {
+	if !u.ConvertibleTo(to) {
+		return Converter{}, fmt.Errorf("error: cannot convert from %q (%s) to %q (%s)", u.text, u.Base(), to.text, to.Base())
+	}
+	if to.scale.Sign() == 0 {
+		return Converter{}, fmt.Errorf("error: cannot convert to %q, which has a zero scale", to.text)
+	}
+	scale := new(big.Rat).Quo(u.scale, to.scale)
+	offset := new(big.Rat).Sub(u.offset, to.offset)
+	offset.Quo(offset, to.scale)
+	return Converter{Scale: ratFloat(scale), Offset: ratFloat(offset)}, nil
+}
+
+// Convert converts a value.
+func (c Converter) Convert(x float64) float64 {
+	return x*c.Scale + c.Offset
+}
+
+// ConvertSlice converts values in place; NaN stays NaN.
+func (c Converter) ConvertSlice(values []float64) {
+	if c.Scale == 1 && c.Offset == 0 {
+		return
+	}
+	for i, x := range values {
+		values[i] = x*c.Scale + c.Offset
+	}
+}
+
+// Convert converts value from the units from to the units to.
+func Convert(value float64, from, to string) (float64, error) {
+	f, err := Parse(from)
+	if err != nil {
+		return math.NaN(), err
+	}
+	t, err := Parse(to)
+	if err != nil {
+		return math.NaN(), err
+	}
+	c, err := f.Converter(t)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return c.Convert(value), nil
+}
+
+// one is the rational 1; it must not be modified.
+var one = big.NewRat(1, 1)
+
+// newUnit returns a dimensionless unit of the given scale.
+func newUnit(scale *big.Rat) Unit {
+	return Unit{scale: scale, offset: new(big.Rat)}
+}
+
+// ratFloat returns the float64 nearest x.
+func ratFloat(x *big.Rat) float64 {
+	f, _ := x.Float64()
+	return f
+}
+
+// mul returns u*v; offsets are dropped, a product of shifted units being a
+// product of intervals, as in UDUNITS.
+func (u Unit) mul(v Unit) Unit {
+	w := newUnit(new(big.Rat).Mul(u.scale, v.scale))
+	for i := range w.dims {
+		w.dims[i] = u.dims[i] + v.dims[i]
+	}
+	return w
+}
+
+// pow returns u raised to n; the scale of u must not be zero if n is
+// negative.
+func (u Unit) pow(n int) Unit {
+	scale := new(big.Rat).SetInt64(1)
+	for range max(n, -n) {
+		scale.Mul(scale, u.scale)
+	}
+	if n < 0 {
+		scale.Inv(scale)
+	}
+	w := newUnit(scale)
+	for i := range w.dims {
+		w.dims[i] = u.dims[i] * n
+	}
+	return w
+}
+
+// scaleBy returns u scaled by a number, keeping its offset: "1000 degC"
+// is a thousand degC.
+func (u Unit) scaleBy(x *big.Rat) Unit {
+	u.scale = new(big.Rat).Mul(u.scale, x)
+	return u
+}
@@ -0,0 +1,151 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		units string
+		base  string
+	}{
+		{"kg m-2 s-1", "m-2 kg s-1"},
+		{"kg/m2/s", "m-2 kg s-1"},
+		{"g m-2 s-1", "0.001 m-2 kg s-1"},
+		{"hPa", "100 m-1 kg s-2"},
+		{"t ha-1", "0.1 m-2 kg"},
+		{"kg ha-1 yr-1", "3.1688764640818493e-12 m-2 kg s-1"},
+		{"degC", "K @ 273.15"},
+		{"degF", "0.5555555555555556 K @ 255.37222222222223"},
+		{"m s^-2", "m s-2"},
+		{"m.s-1", "m s-1"},
+		{"W m**-2", "kg s-3"},
+		{"percent", "0.01"},
+		{"1", "1"},
+		{"", "1"},
+		{"days since 1970-01-01", "86400 s @ 0"},
+		{"hours since 2000-01-01 00:00:00", "3600 s @ 9.466848e+08"},
+		{"seconds since 1970-01-01T00:00:00Z", "s @ 0"},
+		{"days since 1970-01-02 00:00:00 +01:00", "86400 s @ 82800"},
+	}
+	for _, tt := range tests {
+		u, err := Parse(tt.units)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.units, err)
+			continue
+		}
+		if got := u.Base(); got != tt.base {
+			t.Errorf("Parse(%q).Base() = %q, want %q", tt.units, got, tt.base)
+		}
+		if got := u.String(); got != tt.units {
+			t.Errorf("Parse(%q).String() = %q", tt.units, got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{"furlongs", "m/0", "m s-", "1e999 m", "degC since 2000-01-01", "days since 2000-13-01"} {
+		if u, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", s, u.Base())
+		}
+	}
+}
+
+func TestUnitKinds(t *testing.T) {
+	days := MustParse("days since 2000-01-01")
+	if !days.IsTime() || !days.HasReference() {
+		t.Errorf("%q: IsTime %v, HasReference %v", days, days.IsTime(), days.HasReference())
+	}
+	hours := MustParse("h")
+	if !hours.IsTime() || hours.HasReference() {
+		t.Errorf("%q: IsTime %v, HasReference %v", hours, hours.IsTime(), hours.HasReference())
+	}
+	if days.ConvertibleTo(hours) {
+		t.Errorf("%q is convertible to %q", days, hours)
+	}
+	if !MustParse("percent").Dimensionless() || MustParse("m").Dimensionless() {
+		t.Error("wrong Dimensionless")
+	}
+	if _, err := MustParse("kg").Converter(MustParse("m")); err == nil {
+		t.Error("converted kg to m")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    float64
+		from, to string
+		want     float64
+	}{
+		{0, "degC", "degF", 32},
+		{100, "degC", "degF", 212},
+		{-40, "degC", "degF", -40},
+		{32, "degF", "degC", 0},
+		{212, "degF", "degC", 100},
+		{0, "K", "degC", -273.15},
+		{273.15, "K", "degC", 0},
+		{0, "degC", "K", 273.15},
+		{1013.25, "hPa", "Pa", 101325},
+		{101325, "Pa", "hPa", 1013.25},
+		{1, "kg m-2 s-1", "kg m-2 day-1", 86400},
+		{1, "kg m-2 s-1", "g m-2 h-1", 3.6e6},
+		{1, "t ha-1", "kg m-2", 0.1},
+		{1, "km", "m", 1000},
+		{1, "mm", "m", 0.001},
+		{1, "day", "s", 86400},
+		{1, "days since 2000-01-01", "hours since 2000-01-01", 24},
+		{0, "days since 2000-01-02", "hours since 2000-01-01 00:00:00", 24},
+		{0, "days since 2000-01-01", "seconds since 1970-01-01", 946684800},
+		{50, "percent", "1", 0.5},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.value, tt.from, tt.to)
+		if err != nil {
+			t.Errorf("Convert(%v, %q, %q): %v", tt.value, tt.from, tt.to, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Convert(%v, %q, %q) = %v, want %v", tt.value, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	pairs := [][2]string{
+		{"degC", "degF"},
+		{"K", "degC"},
+		{"K", "degF"},
+		{"degC", "degR"},
+		{"hPa", "Pa"},
+		{"kg m-2 s-1", "g cm-2 day-1"},
+		{"days since 2000-01-01", "hours since 1970-01-01"},
+	}
+	for _, p := range pairs {
+		for _, x := range []float64{0, 1, -40, 100, 273.15, 1e6} {
+			y, err := Convert(x, p[0], p[1])
+			if err != nil {
+				t.Fatalf("Convert(%v, %q, %q): %v", x, p[0], p[1], err)
+			}
+			back, err := Convert(y, p[1], p[0])
+			if err != nil {
+				t.Fatalf("Convert(%v, %q, %q): %v", y, p[1], p[0], err)
+			}
+			if math.Abs(back-x) > 1e-12*math.Max(1, math.Abs(x)) {
+				t.Errorf("%v %s -> %v %s -> %v %s", x, p[0], y, p[1], back, p[0])
+			}
+		}
+	}
+}
+
+func TestConvertSlice(t *testing.T) {
+	c, err := MustParse("degC").Converter(MustParse("K"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := []float64{0, math.NaN(), -273.15}
+	c.ConvertSlice(values)
+	if values[0] != 273.15 || !math.IsNaN(values[1]) || values[2] != 0 {
+		t.Errorf("ConvertSlice = %v", values)
+	}
+}
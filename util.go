@@ -1,449 +1,128 @@
 package netcdf4
 
-import "fmt"
+import "iter"
 
-//type Set map[interface{}]bool
-//
-//func NewSet() Set {
-//	return make(map[interface{}]bool)
-//}
-//
-//func (s Set) Has(v interface{}) bool {
-//	_, ok := s[v]
-//	return ok
-//}
-//
-//func (s Set) Add(v interface{}) {
-//	s[v] = true
-//}
-//
-//func (s Set) Erase(v interface{}) {
-//	delete(s, v)
-//}
-//
-//type Multimap map[interface{}]map[interface{}]bool
-//
-//func NewMultimap() Multimap {
-//	return make(map[interface{}]map[interface{}]bool)
-//}
-//
-//func (m Multimap) Add(key, value interface{}) {
-//	if !m.HasKey(key) {
-//		m[key] = make(map[interface{}]bool)
-//	}
-//	m[key][value] = true
-//}
-//
-//func (m Multimap) HasKey(key interface{}) bool {
-//	_, ok := m[key]
-//	return ok
-//}
-//
-//func (m Multimap) Has(key, value interface{}) bool {
-//	if !m.HasKey(key) {
-//		return false
-//	}
-//
-//	_, ok := m[key][value]
-//	return ok
-//}
-//
-//func (m Multimap) EqualRange(key interface{}) []interface{} {
-//	if !m.HasKey(key) {
-//		return nil
-//	}
-//
-//	ans := make([]interface{}, len(m[key]))
-//	i := 0
-//	for v := range m[key] {
-//		ans[i] = v
-//		i++
-//	}
-//
-//	return ans
-//}
-//
-//func (m Multimap) EraseKey(key interface{}) error {
-//	if !m.HasKey(key) {
-//		return fmt.Errorf("key %v not present in Multimap", key)
-//	}
-//
-//	delete(m, key)
-//	return nil
-//}
-//
-//func (m Multimap) Erase(key, value interface{}) error {
-//	if !m.Has(key, value) {
-//		return fmt.Errorf("key value pair [%v, %v] not present in Multimap", key, value)
-//	}
-//
-//	delete(m[key], value)
-//	if len(m[key]) == 0 {
-//		delete(m, key)
-//	}
-//
-//	return nil
-//}
-//
-//func (m Multimap) GetAllPair() ([]interface{}, []interface{}) {
-//	keys := make([]interface{}, 0)
-//	fields := make([]interface{}, 0)
-//	for key := range m {
-//		for v := range m[key] {
-//			keys = append(keys, key)
-//			fields = append(fields, v)
-//		}
-//	}
-//	return keys, fields
-//}
-//
-//func (m Multimap) Length() int {
-//	ans := 0
-//
-//	for _, vm := range m {
-//		ans += len(vm)
-//	}
-//
-//	return ans
-//}
-//
-//func (m Multimap) Size() int {
-//	return len(m)
-//}
-
-///////////////////////////////////////////////////
-
-// type SetG map[*Group]bool
-
-// func NewSetG() SetG {
-// 	return make(map[*Group]bool)
-// }
-
-// func (s SetG) Has(v *Group) bool {
-// 	_, ok := s[v]
-// 	return ok
-// }
-
-// func (s SetG) Add(v *Group) {
-// 	s[v] = true
-// }
-
-// func (s SetG) Erase(v *Group) {
-// 	delete(s, v)
-// }
-
-///////////////////////////////////////////////////
-type SetD map[Dim]bool
-
-func NewSetD() SetD {
-	return make(map[Dim]bool)
-}
-
-func (s SetD) Has(v Dim) bool {
-	_, ok := s[v]
-	return ok
-}
-
-func (s SetD) Add(v Dim) {
-	s[v] = true
-}
-
-func (s SetD) Erase(v Dim) {
-	delete(s, v)
-}
-
-///////////////////////////////////////////////////
-type SetV map[Var]bool
-
-func NewSetV() SetV {
-	return make(map[Var]bool)
-}
-
-func (s SetV) Has(v Var) bool {
-	_, ok := s[v]
-	return ok
+// Entry is a named member of a Collection.
+type Entry[T any] struct {
+	Name  string
+	Value T
 }
 
-func (s SetV) Add(v Var) {
-	s[v] = true
-}
-
-func (s SetV) Erase(v Var) {
-	delete(s, v)
-}
+// Collection is an ordered multimap from names to netCDF objects. Entries
+// keep the order in which they were found in the file (creation, i.e. ID,
+// order within a group) so ranging over a Collection is reproducible. Names
+// need not be unique: a dimension called "time" may exist in several groups.
+type Collection[T any] []Entry[T]
 
-///////////////////////////////////////////////////
-// something is wrong about the general type of Multimap with interface{}, no reason is found, thus, we use this one
-type MultimapG map[string]map[*Group]bool
+// Collections returned by GetGroupsM, GetDimsM and GetVarsM.
+type (
+	Groups = Collection[*Group]
+	Dims   = Collection[Dim]
+	Vars   = Collection[Var]
+)
 
-func NewMultimapG() MultimapG {
-	return make(map[string]map[*Group]bool)
+// Add appends value under name.
+func (c *Collection[T]) Add(name string, value T) {
+	*c = append(*c, Entry[T]{Name: name, Value: value})
 }
 
-func (m MultimapG) Add(key string, value *Group) {
-	if !m.HasKey(key) {
-		m[key] = make(map[*Group]bool)
-	}
-	m[key][value] = true
-}
-
-func (m MultimapG) HasKey(key string) bool {
-	_, ok := m[key]
+// HasKey returns true if at least one entry is called name.
+func (c Collection[T]) HasKey(name string) bool {
+	_, ok := c.Get(name)
 	return ok
 }
 
-func (m MultimapG) Has(key string, value *Group) bool {
-	if !m.HasKey(key) {
-		return false
-	}
-
-	_, ok := m[key][value]
-	return ok
-}
-
-func (m MultimapG) EqualRange(key string) []*Group {
-	if !m.HasKey(key) {
-		return nil
-	}
-
-	ans := make([]*Group, len(m[key]))
-	i := 0
-	for v := range m[key] {
-		ans[i] = v
-		i++
-	}
-
-	return ans
-}
-
-func (m MultimapG) EraseKey(key string) error {
-	if !m.HasKey(key) {
-		return fmt.Errorf("key %v not present in MultimapG", key)
-	}
-
-	delete(m, key)
-	return nil
-}
-
-func (m MultimapG) Erase(key string, value *Group) error {
-	if !m.Has(key, value) {
-		return fmt.Errorf("key value pair [%v, %v] not present in MultimapG", key, value)
-	}
-
-	delete(m[key], value)
-	if len(m[key]) == 0 {
-		delete(m, key)
-	}
-
-	return nil
-}
-
-func (m MultimapG) GetAllPair() ([]string, []*Group) {
-	keys := make([]string, 0)
-	fields := make([]Group, 0)
-	for key := range m {
-		for v := range m[key] {
-			keys = append(keys, key)
-			fields = append(fields, v)
+// Get returns the first value called name.
+func (c Collection[T]) Get(name string) (value T, ok bool) {
+	for _, e := range c {
+		if e.Name == name {
+			return e.Value, true
 		}
 	}
-	return keys, fields
+	return value, false
 }
 
-func (m MultimapG) Length() int {
-	ans := 0
-
-	for _, vm := range m {
-		ans += len(vm)
+// EqualRange returns all values called name, in order.
+func (c Collection[T]) EqualRange(name string) []T {
+	var ans []T
+	for _, e := range c {
+		if e.Name == name {
+			ans = append(ans, e.Value)
+		}
 	}
-
 	return ans
 }
 
-func (m MultimapG) Size() int {
-	return len(m)
-}
-
-///////////////////////////////////////////////////
-// something is wrong about the general type of Multimap with interface{}, no reason is found, thus, we use this one
-type MultimapD map[string]map[Dim]bool
-
-func NewMultimapD() MultimapD {
-	return make(map[string]map[Dim]bool)
-}
-
-func (m MultimapD) Add(key string, value Dim) {
-	if !m.HasKey(key) {
-		m[key] = make(map[Dim]bool)
-	}
-	m[key][value] = true
-}
-
-func (m MultimapD) HasKey(key string) bool {
-	_, ok := m[key]
-	return ok
-}
-
-func (m MultimapD) Has(key string, value Dim) bool {
-	if !m.HasKey(key) {
-		return false
+// Names returns the name of every entry, in order. A name appears once per entry.
+func (c Collection[T]) Names() []string {
+	names := make([]string, len(c))
+	for i, e := range c {
+		names[i] = e.Name
 	}
-
-	_, ok := m[key][value]
-	return ok
-}
-
-func (m MultimapD) EqualRange(key string) []Dim {
-	if !m.HasKey(key) {
-		return nil
-	}
-
-	ans := make([]Dim, len(m[key]))
-	i := 0
-	for v := range m[key] {
-		ans[i] = v
-		i++
-	}
-
-	return ans
+	return names
 }
 
-func (m MultimapD) EraseKey(key string) error {
-	if !m.HasKey(key) {
-		return fmt.Errorf("key %v not present in MultimapD", key)
+// Values returns the value of every entry, in order.
+func (c Collection[T]) Values() []T {
+	values := make([]T, len(c))
+	for i, e := range c {
+		values[i] = e.Value
 	}
-
-	delete(m, key)
-	return nil
+	return values
 }
 
-func (m MultimapD) Erase(key string, value Dim) error {
-	if !m.Has(key, value) {
-		return fmt.Errorf("key value pair [%v, %v] not present in MultimapD", key, value)
-	}
-
-	delete(m[key], value)
-	if len(m[key]) == 0 {
-		delete(m, key)
-	}
-
-	return nil
+// GetAllPair returns the names and values of every entry, in order.
+func (c Collection[T]) GetAllPair() ([]string, []T) {
+	return c.Names(), c.Values()
 }
 
-func (m MultimapD) GetAllPair() ([]string, []Dim) {
-	keys := make([]string, 0)
-	fields := make([]Dim, 0)
-	for key := range m {
-		for v := range m[key] {
-			keys = append(keys, key)
-			fields = append(fields, v)
+// All returns an iterator over the (name, value) pairs, in order.
+func (c Collection[T]) All() iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		for _, e := range c {
+			if !yield(e.Name, e.Value) {
+				return
+			}
 		}
 	}
-	return keys, fields
-}
-
-func (m MultimapD) Length() int {
-	ans := 0
-
-	for _, vm := range m {
-		ans += len(vm)
-	}
-
-	return ans
-}
-
-func (m MultimapD) Size() int {
-	return len(m)
 }
 
 ///////////////////////////////////////////////////
-// something is wrong about the general type of Multimap with interface{}, no reason is found, thus, we use this one
-type MultimapV map[string]map[Var]bool
-
-func NewMultimapV() MultimapV {
-	return make(map[string]map[Var]bool)
-}
-
-func (m MultimapV) Add(key string, value Var) {
-	if !m.HasKey(key) {
-		m[key] = make(map[Var]bool)
-	}
-	m[key][value] = true
-}
+type SetD map[Dim]bool
 
-func (m MultimapV) HasKey(key string) bool {
-	_, ok := m[key]
-	return ok
+func NewSetD() SetD {
+	return make(map[Dim]bool)
 }
 
-func (m MultimapV) Has(key string, value Var) bool {
-	if !m.HasKey(key) {
-		return false
-	}
-
-	_, ok := m[key][value]
+func (s SetD) Has(v Dim) bool {
+	_, ok := s[v]
 	return ok
 }
 
-func (m MultimapV) EqualRange(key string) []Var {
-	if !m.HasKey(key) {
-		return nil
-	}
-
-	ans := make([]Var, len(m[key]))
-	i := 0
-	for v := range m[key] {
-		ans[i] = v
-		i++
-	}
-
-	return ans
+func (s SetD) Add(v Dim) {
+	s[v] = true
 }
 
-func (m MultimapV) EraseKey(key string) error {
-	if !m.HasKey(key) {
-		return fmt.Errorf("key %v not present in MultimapV", key)
-	}
-
-	delete(m, key)
-	return nil
+func (s SetD) Erase(v Dim) {
+	delete(s, v)
 }
 
-func (m MultimapV) Erase(key string, value Var) error {
-	if !m.Has(key, value) {
-		return fmt.Errorf("key value pair [%v, %v] not present in MultimapV", key, value)
-	}
-
-	delete(m[key], value)
-	if len(m[key]) == 0 {
-		delete(m, key)
-	}
+///////////////////////////////////////////////////
+type SetV map[Var]bool
 
-	return nil
+func NewSetV() SetV {
+	return make(map[Var]bool)
 }
 
-func (m MultimapV) GetAllPair() ([]string, []Var) {
-	keys := make([]string, 0)
-	fields := make([]Var, 0)
-	for key := range m {
-		for v := range m[key] {
-			keys = append(keys, key)
-			fields = append(fields, v)
-		}
-	}
-	return keys, fields
+func (s SetV) Has(v Var) bool {
+	_, ok := s[v]
+	return ok
 }
 
-func (m MultimapV) Length() int {
-	ans := 0
-
-	for _, vm := range m {
-		ans += len(vm)
-	}
-
-	return ans
+func (s SetV) Add(v Var) {
+	s[v] = true
 }
 
-func (m MultimapV) Size() int {
-	return len(m)
+func (s SetV) Erase(v Var) {
+	delete(s, v)
 }
@@ -2,6 +2,7 @@ package netcdf4
 
 import (
 	"fmt"
+	"reflect"
 )
 
 type Var struct {
@@ -27,7 +28,7 @@ func NewVar(group Group, vID ID) (v Var) {
 }
 
 // Gets parent group.
-func (v Var) GetParentGroup() Group {
+func (v Var) GetParentGroup() *Group {
 	return NewGroup(v.groupId)
 }
 
@@ -40,21 +41,38 @@ func (v Var) GetGrpId() ID {
 	return v.groupId
 }
 
-func (v Var) DataLength() (int, error) {
+// Shape returns the current size of each dimension of the variable; for
+// unlimited dimensions this is the current number of records. A scalar
+// variable has an empty shape.
+func (v Var) Shape() ([]int, error) {
 	ncDims, err := v.GetDims()
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	shape := make([]int, len(ncDims))
+	for i, dim := range ncDims {
+		if shape[i], err = dim.GetSize(); err != nil {
+			return nil, err
+		}
 	}
+	return shape, nil
+}
+
+// IsScalar returns true if the variable has no dimensions.
+func (v Var) IsScalar() (bool, error) {
+	nDims, err := v.GetDimCount()
+	return nDims == 0, err
+}
 
-	if len(ncDims) == 0 { //scalar
-		return 1, nil
+// DataLength returns the number of values the variable holds: the product of
+// its shape, 1 for a scalar.
+func (v Var) DataLength() (int, error) {
+	shape, err := v.Shape()
+	if err != nil {
+		return 0, err
 	}
 	n := 1
-	for _, dim := range ncDims {
-		dimLen, err := dim.GetSize() //consider the unlimited dims
-		if err != nil {
-			return 0, err
-		}
+	for _, dimLen := range shape {
 		n *= dimLen
 	}
 	return n, nil
@@ -135,7 +153,7 @@ func (v Var) GetDims() ([]Dim, error) {
 
 	ncDims := make([]Dim, dimCount)
 	for i := 0; i < dimCount; i++ {
-		ncDims[i] = NewDim(v.GetParentGroup(), dimIds[i])
+		ncDims[i] = NewDim(*v.GetParentGroup(), dimIds[i])
 	}
 
 	return ncDims, nil
@@ -173,6 +191,42 @@ func (v Var) GetName() (string, error) {
 	return NcInqVarname(v.groupId, v.myId)
 }
 
+// RenameTo attempts to rename the variable to name
+func (v Var) RenameTo(name string) error {
+	if v.IsNull() {
+		return fmt.Errorf("error: attempt to invoke RenameTo on a Null variable")
+	}
+	CheckDefineMode(v.groupId)
+	return NcRenameVar(v.groupId, v.myId, name)
+}
+
+// GetAtts gets the attributes of this variable in the order they are stored.
+func (v Var) GetAtts() ([]Att, error) {
+	if v.IsNull() {
+		return nil, fmt.Errorf("error: attempt to invoke GetAtts on a Null variable")
+	}
+	return getAtts(v.groupId, v.myId)
+}
+
+// GetAtt gets the attribute name of this variable.
+func (v Var) GetAtt(name string) (Att, error) {
+	if v.IsNull() {
+		return NewAttNull(), fmt.Errorf("error: attempt to invoke GetAtt on a Null variable")
+	}
+	return getAtt(v.groupId, v.myId, name)
+}
+
+// PutAtt creates or replaces the attribute name of this variable. value is
+// a string, a []string, or a number or slice of numbers stored with the
+// matching netCDF type.
+func (v Var) PutAtt(name string, value interface{}) (Att, error) {
+	if v.IsNull() {
+		return NewAttNull(), fmt.Errorf("error: attempt to invoke PutAtt on a Null variable")
+	}
+	CheckDefineMode(v.groupId)
+	return putAtt(v.groupId, v.myId, name, value)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////
 //  data writing
@@ -204,14 +258,120 @@ func (v Var) GetName() (string, error) {
 // The problem is the memory layout of the data in C and go are different, thus, it is difficult to use the C API in go
 // Write the entire data into the netCDF variable.
 
-func (v Var) PutValAll(data interface{}) {
-	//CheckDataMode(groupId);
-	//varType, _ := v.GetType()
-	//if varType.IsComplex() {
-	//	nc_put_var(groupId, myId, dataValues)
-	//} else {
-	NcPutVarDouble(v.groupId, v.myId, data)
-	//}
+func (v Var) PutValAll(data interface{}) error {
+	shape, err := v.Shape()
+	if err != nil {
+		return err
+	}
+	return v.PutSlab(make([]int, len(shape)), shape, data)
+}
+
+// PutScalar writes value, a Go number or a string, into a scalar variable.
+func (v Var) PutScalar(value interface{}) error {
+	if err := v.checkScalar("PutScalar"); err != nil {
+		return err
+	}
+	var data interface{}
+	switch x := value.(type) {
+	case string:
+		return v.PutStrings([]string{x})
+	case int8:
+		data = []int8{x}
+	case uint8:
+		data = []uint8{x}
+	case int16:
+		data = []int16{x}
+	case uint16:
+		data = []uint16{x}
+	case int32:
+		data = []int32{x}
+	case uint32:
+		data = []uint32{x}
+	case int64:
+		data = []int64{x}
+	case uint64:
+		data = []uint64{x}
+	case int:
+		data = []int64{int64(x)}
+	case uint:
+		data = []uint64{uint64(x)}
+	case float32:
+		data = []float32{x}
+	case float64:
+		data = []float64{x}
+	default:
+		return fmt.Errorf("error: unsupported scalar type %T", value)
+	}
+	return v.PutSlab(nil, nil, data)
+}
+
+func (v Var) checkScalar(context string) error {
+	if v.IsNull() {
+		return fmt.Errorf("error: attempt to invoke %s on a Null variable", context)
+	}
+	isScalar, err := v.IsScalar()
+	if err != nil {
+		return err
+	}
+	if !isScalar {
+		return fmt.Errorf("error: %s needs a scalar variable", context)
+	}
+	return nil
+}
+
+// PutSlab writes data into the hyperslab of the variable that begins at
+// start and spans count values along each dimension. data is a slice of a
+// fixed size numeric type in row-major order, or an *Array of one; the
+// values are converted to the type of the variable.
+func (v Var) PutSlab(start, count []int, data interface{}) error {
+	if v.IsNull() {
+		return fmt.Errorf("error: attempt to invoke PutSlab on a Null variable")
+	}
+	nDims, err := v.GetDimCount()
+	if err != nil {
+		return err
+	}
+	if len(start) != nDims || len(count) != nDims {
+		return fmt.Errorf("error: PutSlab needs %d start and count values, got %d and %d", nDims, len(start), len(count))
+	}
+	if err := CheckDataMode(v.groupId); err != nil {
+		return err
+	}
+	return NcPutVara(v.groupId, v.myId, toSizeT(start), toSizeT(count), unwrapArray(data))
+}
+
+// Append writes data as new records at the end of the variable's first
+// unlimited dimension and returns the new number of records. data holds
+// whole records in row-major order, as a slice or an *Array, so its length
+// must be a multiple of the record size, the product of the sizes of the
+// other dimensions.
+func (v Var) Append(data interface{}) (int, error) {
+	if v.IsNull() {
+		return 0, fmt.Errorf("error: attempt to invoke Append on a Null variable")
+	}
+	dims, err := v.GetDims()
+	if err != nil {
+		return 0, err
+	}
+	recIdx, err := recordDimIndex(dims)
+	if err != nil {
+		return 0, err
+	}
+	if recIdx < 0 {
+		return 0, fmt.Errorf("error: Append needs a variable with an unlimited dimension")
+	}
+	first, err := dims[recIdx].GetSize()
+	if err != nil {
+		return 0, err
+	}
+	start, count, err := recordSlab(dims, recIdx, first, data)
+	if err != nil {
+		return 0, err
+	}
+	if err := v.PutSlab(start, count, data); err != nil {
+		return 0, err
+	}
+	return first + count[recIdx], nil
 }
 
 //func  (v Var) checkData(data interface{}) error {
@@ -224,6 +384,68 @@ func (v Var) PutValAll(data interface{}) {
 
 // Data reading
 
+// GetSlab reads the hyperslab of the variable that begins at start and spans
+// count values along each dimension into data, a slice of a fixed size
+// numeric type with exactly that many values, or an *Array of one. The values
+// are converted from the type of the variable.
+func (v Var) GetSlab(start, count []int, data interface{}) error {
+	if v.IsNull() {
+		return fmt.Errorf("error: attempt to invoke GetSlab on a Null variable")
+	}
+	nDims, err := v.GetDimCount()
+	if err != nil {
+		return err
+	}
+	if len(start) != nDims || len(count) != nDims {
+		return fmt.Errorf("error: GetSlab needs %d start and count values, got %d and %d", nDims, len(start), len(count))
+	}
+	return NcGetVara(v.groupId, v.myId, toSizeT(start), toSizeT(count), unwrapArray(data))
+}
+
+// Scalar reads a scalar variable and returns its value as the Go type
+// matching the variable type, e.g. float32 for Float and string for String
+// or Char.
+func (v Var) Scalar() (interface{}, error) {
+	if err := v.checkScalar("Scalar"); err != nil {
+		return nil, err
+	}
+	t, err := v.GetType()
+	if err != nil {
+		return nil, err
+	}
+	if t.GetId() == String.GetId() || t.GetId() == Char.GetId() {
+		return v.ScalarString()
+	}
+	data, err := t.newSlice(1)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.GetSlab(nil, nil, data); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(data).Index(0).Interface(), nil
+}
+
+// ScalarFloat64 reads a numeric scalar variable as a float64.
+func (v Var) ScalarFloat64() (float64, error) {
+	if err := v.checkScalar("ScalarFloat64"); err != nil {
+		return 0, err
+	}
+	data := []float64{0}
+	err := v.GetSlab(nil, nil, data)
+	return data[0], err
+}
+
+// ScalarInt64 reads a numeric scalar variable as an int64.
+func (v Var) ScalarInt64() (int64, error) {
+	if err := v.checkScalar("ScalarInt64"); err != nil {
+		return 0, err
+	}
+	data := []int64{0}
+	err := v.GetSlab(nil, nil, data)
+	return data[0], err
+}
+
 // Reads the entire data of the netCDF variable.
 
 // The name of this variable.
@@ -0,0 +1,152 @@
+package netcdf4
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// SkipGroup is used as a return value from a WalkFunc to indicate that the
+// group named in the call is to be skipped. When returned for any other
+// object it skips the remaining contents of the enclosing group.
+var SkipGroup = errors.New("skip this group")
+
+// SkipAll is used as a return value from a WalkFunc to indicate that all
+// remaining objects are to be skipped.
+var SkipAll = errors.New("skip everything and stop the walk")
+
+// WalkFunc is the type of the function called by Walk to visit each object.
+//
+// Paths of groups, dimensions and variables are full paths as accepted by
+// Lookup, e.g. "/USA/Wyoming/average_temperature". Attributes are written
+// the way CDL writes them: "/USA/Wyoming/average_temperature:units" for a
+// variable attribute and "/USA:title" (or "/:title" in the root group) for
+// a group attribute.
+type WalkFunc func(path string, obj Object) error
+
+// Walk walks the hierarchy rooted at g, calling fn for each group,
+// dimension, variable and attribute in file order. Within a group fn sees
+// the group itself, then its dimensions, its variables each followed by
+// their attributes, the group attributes and finally the child groups.
+//
+// If fn returns SkipGroup for a group its contents are not visited. Any
+// other error returned by fn, or met while reading the file, stops the
+// walk and is returned, except SkipAll which stops it and returns nil.
+func (g *Group) Walk(fn WalkFunc) error {
+	if g.IsNull() {
+		return fmt.Errorf("error: attempt to invoke Walk on a Null group")
+	}
+	path, err := g.Path()
+	if err != nil {
+		return err
+	}
+	err = walkGroup(g, path, fn)
+	if err == SkipAll {
+		return nil
+	}
+	return err
+}
+
+// Objects returns an iterator over the same (path, object) pairs Walk
+// visits. Iteration stops early on the first error reading the file; use
+// Walk when those errors matter.
+func (g *Group) Objects() iter.Seq2[string, Object] {
+	return func(yield func(string, Object) bool) {
+		g.Walk(func(path string, obj Object) error {
+			if !yield(path, obj) {
+				return SkipAll
+			}
+			return nil
+		})
+	}
+}
+
+func walkGroup(g *Group, path string, fn WalkFunc) error {
+	if err := fn(path, g); err != nil {
+		if err == SkipGroup {
+			return nil
+		}
+		return err
+	}
+	if err := walkGroupContents(g, path, fn); err != SkipGroup {
+		return err
+	}
+	return nil
+}
+
+func walkGroupContents(g *Group, path string, fn WalkFunc) error {
+	prefix := path
+	if prefix != "/" {
+		prefix += "/"
+	}
+
+	_, dimIds, err := NcInqDimids(g.id, false)
+	if err != nil {
+		return err
+	}
+	for _, dimID := range dimIds {
+		dim := NewDim(*g, dimID)
+		name, err := dim.Name()
+		if err != nil {
+			return err
+		}
+		if err := fn(prefix+name, dim); err != nil {
+			return err
+		}
+	}
+
+	_, varIds, err := NcInqVarids(g.id)
+	if err != nil {
+		return err
+	}
+	for _, varID := range varIds {
+		v := NewVar(*g, varID)
+		name, err := v.GetName()
+		if err != nil {
+			return err
+		}
+		if err := fn(prefix+name, v); err != nil {
+			return err
+		}
+		atts, err := v.GetAtts()
+		if err != nil {
+			return err
+		}
+		if err := walkAtts(atts, prefix+name, fn); err != nil {
+			return err
+		}
+	}
+
+	atts, err := g.GetAtts()
+	if err != nil {
+		return err
+	}
+	if err := walkAtts(atts, path, fn); err != nil {
+		return err
+	}
+
+	_, grpIds, err := NcInqGrps(g.id)
+	if err != nil {
+		return err
+	}
+	for _, grpID := range grpIds {
+		child := NewGroup(grpID)
+		name, err := child.Name(false)
+		if err != nil {
+			return err
+		}
+		if err := walkGroup(child, prefix+name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkAtts(atts []Att, owner string, fn WalkFunc) error {
+	for _, a := range atts {
+		if err := fn(owner+":"+a.Name(), a); err != nil {
+			return err
+		}
+	}
+	return nil
+}